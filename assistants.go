@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// isGoreadmeAssistant checks if the assistant was created by goreadme. assistants created
+// before they were marked with metadata are recognised by their name.
+func isGoreadmeAssistant(assistant Assistant) bool {
+	return assistant.Metadata[assistantMetadataKey] == assistantName || assistant.Name == assistantName
+}
+
+// listGoreadmeAssistants returns the assistants of the account that were created by goreadme,
+// or every assistant of the account if all is true.
+func listGoreadmeAssistants(client ChatGPTService, all bool) ([]Assistant, error) {
+	assistants, err := client.ListAssistants(ListOptions{}).All()
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return assistants, nil
+	}
+	return slices.DeleteFunc(assistants, func(assistant Assistant) bool {
+		return !isGoreadmeAssistant(assistant)
+	}), nil
+}
+
+// assistantInstructions renders the instructions of the assistant from the prompt template.
+// the template is rendered without any project, so only the instructions that apply to
+// every project are included.
+func assistantInstructions(text string) (string, error) {
+	return renderPrompt(text, PromptData{})
+}
+
+// syncAssistantInstructions updates the instructions of the assistant if they differ from
+// the provided instructions, so that they never drift from the local prompt template.
+//
+// Parameters:
+//   - client: The client used to make requests to the ChatGPT API.
+//   - id: The ID of the assistant.
+//   - instructions: The instructions rendered from the prompt template.
+//
+// Returns:
+//   - bool: true if the instructions of the assistant were updated.
+//   - error: An error if the assistant cannot be retrieved or updated.
+func syncAssistantInstructions(client ChatGPTService, id, instructions string) (bool, error) {
+	assistant, err := client.GetAssistant(id)
+	if err != nil {
+		return false, err
+	}
+
+	if assistant.Instructions == instructions {
+		log.Debug(fmt.Sprintf("instructions of assistant %s are up to date", id))
+		return false, nil
+	}
+
+	if _, err := client.UpdateAssistant(id, AssistantUpdate{Instructions: &instructions}); err != nil {
+		return false, err
+	}
+	log.Debug(fmt.Sprintf("updated instructions of assistant %s", id))
+	return true, nil
+}
+
+// formatAssistant formats the details of an assistant, one field per line.
+func formatAssistant(assistant Assistant) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("id             %s\n", assistant.Id))
+	builder.WriteString(fmt.Sprintf("name           %s\n", assistant.Name))
+	builder.WriteString(fmt.Sprintf("model          %s\n", assistant.Model))
+	builder.WriteString(fmt.Sprintf("created        %s\n", time.Unix(assistant.CreatedAt, 0).Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("vectorStores   %s\n", strings.Join(assistant.ToolResources.FileSearch.VectorStoreIds, ", ")))
+
+	tools := []string{}
+	for _, tool := range assistant.Tools {
+		tools = append(tools, tool.Type)
+	}
+	builder.WriteString(fmt.Sprintf("tools          %s\n", strings.Join(tools, ", ")))
+	builder.WriteString(fmt.Sprintf("instructions\n%s\n", assistant.Instructions))
+	return builder.String()
+}
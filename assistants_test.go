@@ -0,0 +1,103 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fakeAssistantService implements the assistant methods of ChatGPTService used by
+// the assistant commands, recording the updates made to the assistant.
+type fakeAssistantService struct {
+	ChatGPTService
+	assistants []Assistant
+	updates    []AssistantUpdate
+}
+
+func (service *fakeAssistantService) GetAssistant(id string) (Assistant, error) {
+	for _, assistant := range service.assistants {
+		if assistant.Id == id {
+			return assistant, nil
+		}
+	}
+	return Assistant{}, ChatGPTError{Code: 404, Type: ChatGPTErrorTypeAPI}
+}
+
+func (service *fakeAssistantService) UpdateAssistant(id string, update AssistantUpdate) (Assistant, error) {
+	service.updates = append(service.updates, update)
+	return Assistant{Id: id}, nil
+}
+
+func (service *fakeAssistantService) ListAssistants(opts ListOptions) *ListIterator[Assistant] {
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[Assistant], error) {
+		return ListResponse[Assistant]{Data: service.assistants}, nil
+	})
+}
+
+// TestListGoreadmeAssistants tests that only assistants marked with the goreadme metadata,
+// or named goreadme, are listed unless every assistant is requested.
+func TestListGoreadmeAssistants(t *testing.T) {
+	service := &fakeAssistantService{
+		assistants: []Assistant{
+			{Id: "asst_metadata", Metadata: map[string]string{assistantMetadataKey: assistantName}},
+			{Id: "asst_name", Name: assistantName},
+			{Id: "asst_other", Name: "other"},
+		},
+	}
+
+	tests := []struct {
+		all  bool
+		want []string
+	}{
+		{all: false, want: []string{"asst_metadata", "asst_name"}},
+		{all: true, want: []string{"asst_metadata", "asst_name", "asst_other"}},
+	}
+
+	for _, test := range tests {
+		assistants, err := listGoreadmeAssistants(service, test.all)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ids := []string{}
+		for _, assistant := range assistants {
+			ids = append(ids, assistant.Id)
+		}
+		if !slices.Equal(ids, test.want) {
+			t.Errorf("got: %v, want: %v", ids, test.want)
+		}
+	}
+}
+
+// TestSyncAssistantInstructions tests that the instructions of the assistant are only
+// updated when they differ from the instructions rendered from the prompt template.
+func TestSyncAssistantInstructions(t *testing.T) {
+	instructions, err := assistantInstructions(DefaultPromptTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(instructions, "The project contains") {
+		t.Errorf("expected instructions without project details, got: %s", instructions)
+	}
+
+	service := &fakeAssistantService{
+		assistants: []Assistant{
+			{Id: "asst_current", Instructions: instructions},
+			{Id: "asst_outdated", Instructions: "old instructions"},
+		},
+	}
+
+	updated, err := syncAssistantInstructions(service, "asst_current", instructions)
+	if err != nil || updated {
+		t.Errorf("got: %v (%v), want: %v", updated, err, false)
+	}
+
+	updated, err = syncAssistantInstructions(service, "asst_outdated", instructions)
+	if err != nil || !updated {
+		t.Errorf("got: %v (%v), want: %v", updated, err, true)
+	}
+
+	if len(service.updates) != 1 || *service.updates[0].Instructions != instructions {
+		t.Errorf("got: %+v, want: a single update of the instructions", service.updates)
+	}
+}
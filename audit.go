@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	// DefaultAuditLogMaxSize is the size in bytes after which the audit log is rotated
+	DefaultAuditLogMaxSize = 10 << 20
+	// DefaultAuditLogMaxBackups is the number of rotated audit logs that are kept
+	DefaultAuditLogMaxBackups = 5
+)
+
+// defaultAuditLog is the audit log of the clients created using NewChatGPTAssistantClient.
+// it is configured once for the whole process by configureAuditLog, and is nil if the
+// audit log is disabled.
+var defaultAuditLog *AuditLog
+
+// AuditFile is a file uploaded by a request, identified by its name and the hash of its contents.
+type AuditFile struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// AuditRecord is a single outbound request of the audit log. Request and response bodies
+// and headers are never recorded, so the audit log never contains secrets.
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	Endpoint  string    `json:"endpoint"`
+	// Status is the status code of the response, or 0 if no response was received
+	Status     int   `json:"status"`
+	DurationMs int64 `json:"durationMs"`
+	// RequestSize is the size in bytes of the request body
+	RequestSize int64       `json:"requestSize"`
+	Files       []AuditFile `json:"files,omitempty"`
+	// Error is the error of requests that did not receive a response
+	Error string `json:"error,omitempty"`
+}
+
+// newAuditRecord creates the audit record of a request that was started at start.
+//
+// Parameters:
+//   - request: The request that was sent.
+//   - response: The response of the request, or nil if no response was received.
+//   - err: The error of the request, or nil.
+//   - start: The time the request was sent.
+//   - size: The size in bytes of the request body.
+//
+// Returns:
+//   - AuditRecord: The audit record of the request.
+func newAuditRecord(request *http.Request, response *http.Response, err error, start time.Time, size int64) AuditRecord {
+	record := AuditRecord{
+		Timestamp:   start.UTC(),
+		Method:      request.Method,
+		Endpoint:    request.URL.Path,
+		DurationMs:  time.Since(start).Milliseconds(),
+		RequestSize: size,
+	}
+	if response != nil {
+		record.Status = response.StatusCode
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// AuditLog appends audit records to a JSONL file, one record per line. The file is
+// rotated once it exceeds MaxSize, keeping MaxBackups rotated files named <path>.1,
+// <path>.2 and so on, with <path>.1 being the most recent.
+type AuditLog struct {
+	Path string
+	// MaxSize is the size in bytes after which the file is rotated. 0 disables rotation
+	MaxSize int64
+	// MaxBackups is the number of rotated files that are kept
+	MaxBackups int
+	mu         sync.Mutex
+}
+
+// Write appends a record to the audit log, rotating the file first if the record would
+// make it exceed the maximum size. Records are written with a single write to a file
+// opened in append mode, so existing records are never modified.
+func (audit *AuditLog) Write(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+
+	if err := audit.rotate(int64(len(line))); err != nil {
+		return fmt.Errorf("error rotating audit log %s: %w", audit.Path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(audit.Path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(audit.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// rotate rotates the audit log if appending size bytes would make it exceed the maximum size.
+func (audit *AuditLog) rotate(size int64) error {
+	if audit.MaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(audit.Path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && (info.Size() == 0 || info.Size()+size <= audit.MaxSize)) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if audit.MaxBackups <= 0 {
+		return os.Remove(audit.Path)
+	}
+	// shift the rotated files, removing the oldest
+	oldest := fmt.Sprintf("%s.%d", audit.Path, audit.MaxBackups)
+	if err := os.Remove(oldest); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := audit.MaxBackups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", audit.Path, i), fmt.Sprintf("%s.%d", audit.Path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(audit.Path, audit.Path+".1")
+}
+
+// countingReader counts the bytes read from the reader. the count is atomic, as request
+// bodies can still be read by the transport when the response is received.
+type countingReader struct {
+	reader io.Reader
+	count  atomic.Int64
+}
+
+func (reader *countingReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	reader.count.Add(int64(n))
+	return n, err
+}
+
+// configureAuditLog configures the audit log of the process from the audit log flags of the
+// command, falling back to the GOREADME_AUDIT_LOG environment variable for the path. The
+// audit log is disabled if no path is set.
+func configureAuditLog(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	path := cmd.String("audit-log")
+	if !cmd.IsSet("audit-log") {
+		path = os.Getenv("GOREADME_AUDIT_LOG")
+	}
+	if len(path) == 0 {
+		defaultAuditLog = nil
+		return ctx, nil
+	}
+
+	maxSize := int64(DefaultAuditLogMaxSize)
+	if value := cmd.String("audit-log-max-size"); len(value) > 0 {
+		size, err := parseSize(value)
+		if err != nil || size < 0 {
+			return ctx, cli.Exit(fmt.Sprintf("invalid audit log max size %s", value), 1)
+		}
+		maxSize = size
+	}
+
+	defaultAuditLog = &AuditLog{
+		Path:       path,
+		MaxSize:    maxSize,
+		MaxBackups: int(cmd.Int("audit-log-max-backups")),
+	}
+	log.Debug(fmt.Sprintf("writing audit log to %s", path))
+	return ctx, nil
+}
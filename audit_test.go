@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// roundTripperFunc is an http.RoundTripper that answers requests using a function.
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// readAuditRecords reads the records of an audit log file.
+func readAuditRecords(t *testing.T, path string) []AuditRecord {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	records := []AuditRecord{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid audit record %s: %+v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// TestAuditLogRotate tests that the audit log is rotated once it exceeds the maximum
+// size, keeping only the configured number of rotated files.
+func TestAuditLogRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "audit.jsonl")
+	audit := &AuditLog{Path: path, MaxSize: 150, MaxBackups: 2}
+
+	for i := 0; i < 5; i++ {
+		if err := audit.Write(AuditRecord{Method: http.MethodGet, Endpoint: fmt.Sprintf("/v1/models/%d", i), Status: 200}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// every record is larger than half of the maximum size, so each file contains a single record
+	expected := map[string]string{
+		path:        "/v1/models/4",
+		path + ".1": "/v1/models/3",
+		path + ".2": "/v1/models/2",
+	}
+	for file, endpoint := range expected {
+		records := readAuditRecords(t, file)
+		if len(records) != 1 || records[0].Endpoint != endpoint {
+			t.Errorf("%s got: %+v, want: %s", file, records, endpoint)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.3 to be removed, got: %v", path, err)
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("got: %04o, want: %04o", info.Mode().Perm(), 0600)
+	}
+}
+
+// TestAuditUploadFile tests that uploads and JSON requests are recorded with their endpoint,
+// status and request size, and uploaded files with the hash of their contents, without
+// recording the access token or the contents of the request.
+func TestAuditUploadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	client := NewChatGPTAssistantClient("gpt-4o", ChatGPTCredentials{Secret: "test-token"})
+	client.Audit = &AuditLog{Path: path}
+
+	var uploaded int64
+	client.Client = &http.Client{Transport: roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		if request.Body != nil {
+			size, err := io.Copy(io.Discard, request.Body)
+			if err != nil {
+				return nil, err
+			}
+			if request.URL.Path == "/v1/files" {
+				uploaded = size
+			}
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"id": "file_1"}`)),
+			Request:    request,
+		}, nil
+	})}
+
+	content := "print('secret contents')"
+	id, err := client.UploadFile("combined_source_files.py", strings.NewReader(content))
+	if err != nil || id != "file_1" {
+		t.Fatalf("got: %s %v, want: %s", id, err, "file_1")
+	}
+	response, err := client.ExecuteChatGPTRequest(http.MethodPost, APIUrl+"/threads", map[string]string{"a": "b"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	records := readAuditRecords(t, path)
+	if len(records) != 2 {
+		t.Fatalf("got: %d, want: %d", len(records), 2)
+	}
+
+	hash := sha256.Sum256([]byte(content))
+	upload := records[0]
+	if upload.Method != http.MethodPost || upload.Endpoint != "/v1/files" || upload.Status != http.StatusOK || upload.RequestSize != uploaded {
+		t.Errorf("unexpected upload record %+v", upload)
+	}
+	if len(upload.Files) != 1 || upload.Files[0].Name != "combined_source_files.py" || upload.Files[0].SHA256 != hex.EncodeToString(hash[:]) {
+		t.Errorf("got: %+v, want: %s %s", upload.Files, "combined_source_files.py", hex.EncodeToString(hash[:]))
+	}
+
+	if records[1].Endpoint != "/v1/threads" || records[1].RequestSize != int64(len(`{"a":"b"}`)) || len(records[1].Files) != 0 {
+		t.Errorf("unexpected request record %+v", records[1])
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"test-token", "secret contents"} {
+		if strings.Contains(string(contents), secret) {
+			t.Errorf("audit log contains %s: %s", secret, contents)
+		}
+	}
+}
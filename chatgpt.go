@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -46,7 +48,8 @@ func NewChatGPTAssistantClient(model string, credentials ChatGPTCredentials) *Ch
 	return &ChatGPTAssistantClient{
 		Model:       model,
 		Credentials: credentials,
-		Client:      &http.Client{},
+		Client:      &http.Client{Transport: defaultTransport},
+		Audit:       defaultAuditLog,
 	}
 }
 
@@ -54,22 +57,51 @@ type ChatGPTService interface {
 	VerifyCredentials() error
 	GetAssistant(id string) (Assistant, error)
 	CreateAssistant(name, description, model, vectorStoreId string) (string, error)
+	UpdateAssistant(id string, update AssistantUpdate) (Assistant, error)
+	DeleteAssistant(id string) error
 	GetVectorStore(id string) (VectorStore, error)
 	GetModel(model string) (Model, error)
 	CreateVectorStore(name string) (string, error)
+	CreateExpiringVectorStore(name string, expiresAfter *VectorStoreExpiresAfter) (VectorStore, error)
+	DeleteVectorStore(id string) error
+	DeleteVectorStoreFile(vectorStoreId, fileId string) error
+	CreateVectorStoreFileBatch(vectorStoreId string, fileIds []string) (VectorStoreFileBatch, error)
+	WaitForFileBatchCompletion(vectorStoreId, batchId string) (VectorStoreFileBatch, error)
 	UploadFile(filename string, file io.Reader) (string, error)
+	GetFile(id string) (File, error)
 	DeleteFile(filename string) error
 	CreateThreadAndRun(assistantId, vectorStoreId string, messages []ThreadMessage) (ThreadRun, error)
+	CreateMessage(threadId string, message ThreadMessage) error
+	CreateRun(threadId, assistantId string) (ThreadRun, error)
 	GetThreadMessages(threadId string) ([]ThreadMessageResponse, error)
+	ListThreadMessages(threadId string, opts ListOptions) *ListIterator[ThreadMessageResponse]
+	ListFiles(opts ListOptions) *ListIterator[File]
+	ListVectorStores(opts ListOptions) *ListIterator[VectorStore]
+	ListVectorStoreFiles(vectorStoreId string, opts ListOptions) *ListIterator[VectorStoreFile]
+	ListAssistants(opts ListOptions) *ListIterator[Assistant]
+	ListRunSteps(threadId, runId string, opts ListOptions) *ListIterator[RunStep]
 	WaitForRunCompletion(threadId, runId string) (ThreadRun, error)
 }
 
 type ChatGPTAssistantClient struct {
 	Credentials ChatGPTCredentials
 	Model       string
+	// Audit records every request sent by the client. requests are not recorded if nil
+	Audit *AuditLog
 	*http.Client
 }
 
+// audit writes the record to the audit log of the client. failing to write the audit log
+// never fails the request, as the request has already been sent.
+func (client *ChatGPTAssistantClient) audit(record AuditRecord) {
+	if client.Audit == nil {
+		return
+	}
+	if err := client.Audit.Write(record); err != nil {
+		log.Warn(fmt.Sprintf("error writing audit log %s: %+v", client.Audit.Path, err))
+	}
+}
+
 // ExecuteChatGPTRequest sends an HTTP request to the specified URL using the provided method and payload.
 // It sets the necessary headers for authorization and content type.
 //
@@ -83,12 +115,14 @@ type ChatGPTAssistantClient struct {
 //   - error: An error if the request could not be created or executed.
 func (client *ChatGPTAssistantClient) ExecuteChatGPTRequest(method, url string, payload any, headers map[string]string) (*http.Response, error) {
 	var buffer io.Reader
+	var size int64
 	if payload != nil {
 		encoded, err := json.Marshal(payload)
 		if err != nil {
 			return nil, err
 		}
 		buffer = bytes.NewBuffer(encoded)
+		size = int64(len(encoded))
 	}
 
 	request, err := http.NewRequest(method, url, buffer)
@@ -103,7 +137,9 @@ func (client *ChatGPTAssistantClient) ExecuteChatGPTRequest(method, url string,
 		request.Header.Add(k, v)
 	}
 
+	start := time.Now()
 	r, err := client.Do(request)
+	client.audit(newAuditRecord(request, r, err, start, size))
 	if err != nil {
 		return nil, err
 	}
@@ -278,6 +314,10 @@ func (client *ChatGPTAssistantClient) CreateAssistant(name, description, model,
 				},
 			},
 		},
+		// mark the assistant so that assistants created by goreadme can be listed
+		"metadata": map[string]string{
+			assistantMetadataKey: assistantName,
+		},
 	}
 
 	headers := map[string]string{
@@ -312,6 +352,66 @@ func (client *ChatGPTAssistantClient) CreateAssistant(name, description, model,
 	}
 }
 
+// UpdateAssistant modifies the assistant with the given ID, changing only the
+// fields set in the update.
+//
+// Parameters:
+//   - id: The ID of the assistant to modify.
+//   - update: The fields of the assistant to change.
+//
+// Returns:
+//   - Assistant: The modified assistant.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (client *ChatGPTAssistantClient) UpdateAssistant(id string, update AssistantUpdate) (Assistant, error) {
+	var assistant Assistant
+
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	response, err := client.ExecuteChatGPTRequest(http.MethodPost, APIUrl+"/assistants/"+id, update, headers)
+	if err != nil {
+		return assistant, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		data, err := io.ReadAll(response.Body)
+		if err != nil {
+			return assistant, err
+		}
+
+		if err := json.Unmarshal(data, &assistant); err != nil {
+			return assistant, err
+		} else {
+			return assistant, nil
+		}
+
+	default:
+		return assistant, NewChatGPTError(response)
+	}
+}
+
+// DeleteAssistant deletes the assistant with the given ID. The vector stores attached
+// to the assistant are not deleted.
+func (client *ChatGPTAssistantClient) DeleteAssistant(id string) error {
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	response, err := client.ExecuteChatGPTRequest(http.MethodDelete, APIUrl+"/assistants/"+id, nil, headers)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return NewChatGPTError(response)
+	}
+	return nil
+}
+
 // CreateVectorStore creates a new vector store with the given name.
 // It sends a POST request to the ChatGPT API to create the vector store.
 //
@@ -326,9 +426,33 @@ func (client *ChatGPTAssistantClient) CreateAssistant(name, description, model,
 // It then executes the request and handles the response. If the request is successful, it returns
 // the ID of the created vector store. Otherwise, it returns an error.
 func (client *ChatGPTAssistantClient) CreateVectorStore(name string) (string, error) {
+	store, err := client.CreateExpiringVectorStore(name, nil)
+	return store.Id, err
+}
+
+// CreateExpiringVectorStore creates a new vector store with the given name, which is
+// deleted by the ChatGPT API once it expires if an expiration policy is provided.
+//
+// Parameters:
+//   - name: The name of the vector store to be created.
+//   - expiresAfter: The expiration policy of the vector store, or nil if it does not expire.
+//
+// Returns:
+//   - VectorStore: The created vector store.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (client *ChatGPTAssistantClient) CreateExpiringVectorStore(name string, expiresAfter *VectorStoreExpiresAfter) (VectorStore, error) {
+	var store VectorStore
+
 	// generate new JSON payload
 	payload := map[string]interface{}{
 		"name": name,
+		// mark the vector store so that vector stores created by goreadme can be listed
+		"metadata": map[string]string{
+			assistantMetadataKey: assistantName,
+		},
+	}
+	if expiresAfter != nil {
+		payload["expires_after"] = expiresAfter
 	}
 	headers := map[string]string{
 		"OpenAI-Beta": "assistants=v2",
@@ -336,7 +460,7 @@ func (client *ChatGPTAssistantClient) CreateVectorStore(name string) (string, er
 
 	response, err := client.ExecuteChatGPTRequest(http.MethodPost, APIUrl+"/vector_stores", payload, headers)
 	if err != nil {
-		return "", err
+		return store, err
 	}
 	defer response.Body.Close()
 
@@ -346,19 +470,150 @@ func (client *ChatGPTAssistantClient) CreateVectorStore(name string) (string, er
 		// and parse JSON structure
 		body, err := io.ReadAll(response.Body)
 		if err != nil {
-			return "", err
+			return store, err
 		}
 
-		var data struct {
-			Id string `json:"id"`
+		if err := json.Unmarshal(body, &store); err != nil {
+			return store, err
 		}
-		if err := json.Unmarshal(body, &data); err != nil {
-			return "", err
+		return store, nil
+
+	default:
+		return store, NewChatGPTError(response)
+	}
+}
+
+// DeleteVectorStore deletes the vector store with the given ID. The files of the
+// vector store are detached, but are not deleted.
+func (client *ChatGPTAssistantClient) DeleteVectorStore(id string) error {
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	response, err := client.ExecuteChatGPTRequest(http.MethodDelete, APIUrl+"/vector_stores/"+id, nil, headers)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return NewChatGPTError(response)
+	}
+	return nil
+}
+
+// DeleteVectorStoreFile detaches the file with the given ID from the vector store. The
+// file itself is not deleted, see DeleteFile.
+func (client *ChatGPTAssistantClient) DeleteVectorStoreFile(vectorStoreId, fileId string) error {
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	url := fmt.Sprintf("%s/vector_stores/%s/files/%s", APIUrl, vectorStoreId, fileId)
+	response, err := client.ExecuteChatGPTRequest(http.MethodDelete, url, nil, headers)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return NewChatGPTError(response)
+	}
+	return nil
+}
+
+// CreateVectorStoreFileBatch adds the uploaded files with the given IDs to the vector
+// store in a single batch. The files are indexed asynchronously, see WaitForFileBatchCompletion.
+//
+// Parameters:
+//   - vectorStoreId: The ID of the vector store to add the files to.
+//   - fileIds: The IDs of the uploaded files.
+//
+// Returns:
+//   - VectorStoreFileBatch: The created file batch.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (client *ChatGPTAssistantClient) CreateVectorStoreFileBatch(vectorStoreId string, fileIds []string) (VectorStoreFileBatch, error) {
+	var batch VectorStoreFileBatch
+
+	payload := map[string]interface{}{
+		"file_ids": fileIds,
+	}
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	url := fmt.Sprintf("%s/vector_stores/%s/file_batches", APIUrl, vectorStoreId)
+	response, err := client.ExecuteChatGPTRequest(http.MethodPost, url, payload, headers)
+	if err != nil {
+		return batch, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		content, err := io.ReadAll(response.Body)
+		if err != nil {
+			return batch, err
+		}
+
+		if err := json.Unmarshal(content, &batch); err != nil {
+			return batch, err
+		} else {
+			return batch, nil
 		}
-		return data.Id, nil
 
 	default:
-		return "", NewChatGPTError(response)
+		return batch, NewChatGPTError(response)
+	}
+}
+
+// WaitForFileBatchCompletion waits for the files of a file batch to be indexed. It polls
+// the ChatGPT API until the status of the batch is "completed", "cancelled" or "failed".
+//
+// Parameters:
+//   - vectorStoreId: The ID of the vector store the files were added to.
+//   - batchId: The ID of the file batch to wait for.
+//
+// Returns:
+//   - VectorStoreFileBatch: The final state of the file batch.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (client *ChatGPTAssistantClient) WaitForFileBatchCompletion(vectorStoreId, batchId string) (VectorStoreFileBatch, error) {
+	var batch VectorStoreFileBatch
+
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	for {
+		url := fmt.Sprintf("%s/vector_stores/%s/file_batches/%s", APIUrl, vectorStoreId, batchId)
+		response, err := client.ExecuteChatGPTRequest(http.MethodGet, url, nil, headers)
+		if err != nil {
+			return batch, err
+		}
+
+		switch response.StatusCode {
+		case http.StatusOK:
+			content, err := io.ReadAll(response.Body)
+			response.Body.Close()
+			if err != nil {
+				return batch, err
+			}
+
+			if err := json.Unmarshal(content, &batch); err != nil {
+				return batch, err
+			}
+
+		default:
+			defer response.Body.Close()
+			return batch, NewChatGPTError(response)
+		}
+
+		switch batch.Status {
+		case "completed", "cancelled", "failed":
+			return batch, nil
+		}
+
+		time.Sleep(time.Second * 1)
 	}
 }
 
@@ -387,6 +642,10 @@ func (client *ChatGPTAssistantClient) CreateThreadAndRun(assistantId, vectorStor
 			},
 		},
 	}
+	// override the model of the assistant with the configured model
+	if len(client.Model) > 0 {
+		payload["model"] = client.Model
+	}
 
 	headers := map[string]string{
 		"OpenAI-Beta": "assistants=v2",
@@ -415,37 +674,136 @@ func (client *ChatGPTAssistantClient) CreateThreadAndRun(assistantId, vectorStor
 	}
 }
 
-func (client *ChatGPTAssistantClient) UploadFile(filename string, content io.Reader) (string, error) {
+// CreateMessage adds a new message to an existing thread.
+//
+// Parameters:
+//   - threadId: The ID of the thread to add the message to.
+//   - message: The message to add to the thread.
+//
+// Returns:
+//   - error: An error if the request fails.
+func (client *ChatGPTAssistantClient) CreateMessage(threadId string, message ThreadMessage) error {
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
+
+	url := fmt.Sprintf("%s/threads/%s/messages", APIUrl, threadId)
+	response, err := client.ExecuteChatGPTRequest(http.MethodPost, url, message, headers)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return NewChatGPTError(response)
+	}
+	return nil
+}
+
+// CreateRun starts a new run of the given assistant on an existing thread.
+//
+// Parameters:
+//   - threadId: The ID of the thread to run.
+//   - assistantId: The ID of the assistant used for the run.
+//
+// Returns:
+//   - ThreadRun: The created run.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (client *ChatGPTAssistantClient) CreateRun(threadId, assistantId string) (ThreadRun, error) {
+	var run ThreadRun
+
+	payload := map[string]interface{}{
+		"assistant_id": assistantId,
+	}
+	if len(client.Model) > 0 {
+		payload["model"] = client.Model
+	}
+
+	headers := map[string]string{
+		"OpenAI-Beta": "assistants=v2",
+	}
 
-	var data bytes.Buffer
-	writer := multipart.NewWriter(&data)
+	url := fmt.Sprintf("%s/threads/%s/runs", APIUrl, threadId)
+	response, err := client.ExecuteChatGPTRequest(http.MethodPost, url, payload, headers)
+	if err != nil {
+		return run, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		content, err := io.ReadAll(response.Body)
+		if err != nil {
+			return run, err
+		}
+		if err := json.Unmarshal(content, &run); err != nil {
+			return run, err
+		} else {
+			return run, nil
+		}
 
+	default:
+		return run, NewChatGPTError(response)
+	}
+}
+
+// writeUploadForm writes the multipart form of a file upload, closing the writer to finalize the form.
+//
+// Parameters:
+//   - writer: The multipart writer of the request body.
+//   - filename: The name of the uploaded file.
+//   - content: The content of the uploaded file.
+//
+// Returns:
+//   - error: An error if the content cannot be read or the form cannot be written.
+func writeUploadForm(writer *multipart.Writer, filename string, content io.Reader) error {
 	part, err := writer.CreateFormFile("file", filename)
 	if err != nil {
-		return "", err
+		return err
 	}
 	// write file content to the form
 	if _, err := io.Copy(part, content); err != nil {
-		return "", err
+		return err
 	}
 	// add purpose field to the form
 	if err := writer.WriteField("purpose", "assistants"); err != nil {
-		return "", err
+		return err
 	}
 	// close the writer to finalize the form
-	if err := writer.Close(); err != nil {
-		return "", err
-	}
+	return writer.Close()
+}
 
-	request, err := http.NewRequest(http.MethodPost, APIUrl+"/files", &data)
+func (client *ChatGPTAssistantClient) UploadFile(filename string, content io.Reader) (string, error) {
+
+	// the form is streamed into the request body, so the content is never held in memory.
+	// the content is hashed as it is streamed, so the upload can be recorded in the audit log
+	reader, pipe := io.Pipe()
+	writer := multipart.NewWriter(pipe)
+	hash := sha256.New()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pipe.CloseWithError(writeUploadForm(writer, filename, io.TeeReader(content, hash)))
+	}()
+
+	body := &countingReader{reader: reader}
+	request, err := http.NewRequest(http.MethodPost, APIUrl+"/files", body)
 	if err != nil {
+		reader.CloseWithError(err)
 		return "", err
 	}
 	// add required request headers
 	request.Header.Add("Authorization", "Bearer "+client.Credentials.Secret)
 	request.Header.Add("Content-Type", writer.FormDataContentType())
 
+	start := time.Now()
 	response, err := client.Do(request)
+	// the request body is closed once the request has been sent, so the form has been written
+	reader.Close()
+	<-done
+	record := newAuditRecord(request, response, err, start, body.count.Load())
+	record.Files = []AuditFile{{Name: filename, SHA256: hex.EncodeToString(hash.Sum(nil))}}
+	client.audit(record)
 	if err != nil {
 		return "", err
 	}
@@ -473,6 +831,42 @@ func (client *ChatGPTAssistantClient) UploadFile(filename string, content io.Rea
 	}
 }
 
+// GetFile retrieves the metadata of an uploaded file by its ID.
+//
+// Parameters:
+//   - id: The ID of the file to retrieve.
+//
+// Returns:
+//   - File: The file metadata, including the name the file was uploaded with.
+//   - error: An error if the request fails or the response cannot be parsed.
+func (client *ChatGPTAssistantClient) GetFile(id string) (File, error) {
+	var file File
+
+	url := fmt.Sprintf("%s/files/%s", APIUrl, id)
+	response, err := client.ExecuteChatGPTRequest(http.MethodGet, url, nil, nil)
+	if err != nil {
+		return file, err
+	}
+	defer response.Body.Close()
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		content, err := io.ReadAll(response.Body)
+		if err != nil {
+			return file, err
+		}
+
+		if err := json.Unmarshal(content, &file); err != nil {
+			return file, err
+		} else {
+			return file, nil
+		}
+
+	default:
+		return file, NewChatGPTError(response)
+	}
+}
+
 func (client *ChatGPTAssistantClient) DeleteFile(id string) error {
 
 	url := fmt.Sprintf("%s/files/%s", APIUrl, id)
@@ -537,29 +931,92 @@ func (client *ChatGPTAssistantClient) WaitForRunCompletion(threadId, runId strin
 	}
 }
 
-// GetThreadMessages retrieves the messages of a specific thread by its ID.
-// It sends a GET request to the ChatGPT API and returns a slice of ThreadMessageResponse.
+// GetThreadMessages retrieves all messages of a specific thread by its ID.
+// Every page of the /threads/{id}/messages endpoint is read, with the newest
+// messages returned first.
 //
 // Parameters:
 //   - threadId: The ID of the thread to retrieve messages from.
 //
 // Returns:
 //   - []ThreadMessageResponse: A slice containing the messages of the thread.
-//   - error: An error object if the request fails or if there is an issue with reading the response.
-//
-// The function handles the following HTTP status codes:
-//   - http.StatusOK: Successfully retrieved the messages.
-//   - Other: Returns a ChatGPTError with the response details.
+//   - error: An error object if any of the page requests fail.
 func (client *ChatGPTAssistantClient) GetThreadMessages(threadId string) ([]ThreadMessageResponse, error) {
+	return client.ListThreadMessages(threadId, ListOptions{Limit: 100, Order: "desc"}).All()
+}
+
+// ListThreadMessages returns an iterator over the messages of the given thread.
+func (client *ChatGPTAssistantClient) ListThreadMessages(threadId string, opts ListOptions) *ListIterator[ThreadMessageResponse] {
+	path := fmt.Sprintf("/threads/%s/messages", threadId)
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[ThreadMessageResponse], error) {
+		return listChatGPTResource[ThreadMessageResponse](client, path, opts)
+	})
+}
+
+// ListFiles returns an iterator over the files uploaded to the ChatGPT account.
+func (client *ChatGPTAssistantClient) ListFiles(opts ListOptions) *ListIterator[File] {
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[File], error) {
+		return listChatGPTResource[File](client, "/files", opts)
+	})
+}
+
+// ListVectorStores returns an iterator over the vector stores of the ChatGPT account.
+func (client *ChatGPTAssistantClient) ListVectorStores(opts ListOptions) *ListIterator[VectorStore] {
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[VectorStore], error) {
+		return listChatGPTResource[VectorStore](client, "/vector_stores", opts)
+	})
+}
+
+// ListVectorStoreFiles returns an iterator over the files attached to the given vector store.
+func (client *ChatGPTAssistantClient) ListVectorStoreFiles(vectorStoreId string, opts ListOptions) *ListIterator[VectorStoreFile] {
+	path := fmt.Sprintf("/vector_stores/%s/files", vectorStoreId)
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[VectorStoreFile], error) {
+		return listChatGPTResource[VectorStoreFile](client, path, opts)
+	})
+}
+
+// ListAssistants returns an iterator over the assistants of the ChatGPT account.
+func (client *ChatGPTAssistantClient) ListAssistants(opts ListOptions) *ListIterator[Assistant] {
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[Assistant], error) {
+		return listChatGPTResource[Assistant](client, "/assistants", opts)
+	})
+}
+
+// ListRunSteps returns an iterator over the steps of the given thread run.
+func (client *ChatGPTAssistantClient) ListRunSteps(threadId, runId string, opts ListOptions) *ListIterator[RunStep] {
+	path := fmt.Sprintf("/threads/%s/runs/%s/steps", threadId, runId)
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[RunStep], error) {
+		return listChatGPTResource[RunStep](client, path, opts)
+	})
+}
+
+// listChatGPTResource fetches a single page from a cursor paginated ChatGPT
+// list endpoint. The list options are sent as query parameters, and the
+// response is decoded into a ListResponse of the requested type.
+//
+// Parameters:
+//   - client: The client used to execute the request.
+//   - path: The path of the list endpoint, relative to APIUrl.
+//   - opts: The cursor and page options for the request.
+//
+// Returns:
+//   - ListResponse[T]: The decoded page, including the cursors of the page.
+//   - error: An error if the request fails or the response cannot be parsed.
+func listChatGPTResource[T any](client *ChatGPTAssistantClient, path string, opts ListOptions) (ListResponse[T], error) {
+	var page ListResponse[T]
 
 	headers := map[string]string{
 		"OpenAI-Beta": "assistants=v2",
 	}
 
-	url := fmt.Sprintf("%s/threads/%s/messages", APIUrl, threadId)
+	url := APIUrl + path
+	if query := opts.Query().Encode(); len(query) > 0 {
+		url += "?" + query
+	}
+
 	response, err := client.ExecuteChatGPTRequest(http.MethodGet, url, nil, headers)
 	if err != nil {
-		return []ThreadMessageResponse{}, err
+		return page, err
 	}
 	defer response.Body.Close()
 
@@ -567,19 +1024,16 @@ func (client *ChatGPTAssistantClient) GetThreadMessages(threadId string) ([]Thre
 	case http.StatusOK:
 		content, err := io.ReadAll(response.Body)
 		if err != nil {
-			return []ThreadMessageResponse{}, err
+			return page, err
 		}
 
-		var payload struct {
-			Data []ThreadMessageResponse `json:"data"`
-		}
-		if err := json.Unmarshal(content, &payload); err != nil {
-			return payload.Data, err
+		if err := json.Unmarshal(content, &page); err != nil {
+			return page, err
 		} else {
-			return payload.Data, nil
+			return page, nil
 		}
 
 	default:
-		return []ThreadMessageResponse{}, NewChatGPTError(response)
+		return page, NewChatGPTError(response)
 	}
 }
@@ -1 +1,144 @@
 package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestClient creates a client sending requests using the given transport.
+func newTestClient(token string, transport http.RoundTripper) *ChatGPTAssistantClient {
+	client := NewChatGPTAssistantClient("gpt-4o", ChatGPTCredentials{Secret: token})
+	client.Client = &http.Client{Transport: transport}
+	client.Audit = nil
+	return client
+}
+
+// rewriteTransport sends every request to the test server instead of the ChatGPT API.
+func rewriteTransport(server *httptest.Server) http.RoundTripper {
+	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		request.URL.Scheme = "http"
+		request.URL.Host = strings.TrimPrefix(server.URL, "http://")
+		return http.DefaultTransport.RoundTrip(request)
+	})
+}
+
+// TestRecordReplay tests that exchanges recorded from a server are replayed in the order
+// they were recorded without contacting the server, and that the access token is scrubbed
+// from the fixture.
+func TestRecordReplay(t *testing.T) {
+	token := "sk-" + "recordtoken1234"
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		calls++
+		writer.Header().Set("Content-Type", "application/json")
+		writer.Header().Set("Set-Cookie", "session=private")
+		switch request.URL.Path {
+		case "/v1/models/gpt-4o":
+			fmt.Fprintf(writer, `{"id": "gpt-4o", "token": "%s"}`, token)
+		case "/v1/files":
+			io.Copy(io.Discard, request.Body)
+			fmt.Fprintf(writer, `{"id": "file_%d"}`, calls)
+		default:
+			writer.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(writer, `{"error": {"message": "not found"}}`)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "fixtures", "client.json")
+	recorder := &RecordingTransport{Path: path, Transport: rewriteTransport(server)}
+	client := newTestClient(token, recorder)
+
+	if _, err := client.GetModel("gpt-4o"); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"file_2", "file_3"} {
+		if id, err := client.UploadFile("main.py", strings.NewReader("print('main')")); err != nil || id != expected {
+			t.Fatalf("got: %s %v, want: %s", id, err, expected)
+		}
+	}
+	if _, err := client.GetAssistant("asst_missing"); err == nil {
+		t.Fatal("expected error for missing assistant")
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{token, "session=private", "print('main')"} {
+		if strings.Contains(string(contents), secret) {
+			t.Errorf("fixture contains %s: %s", secret, contents)
+		}
+	}
+	if !strings.Contains(string(contents), scrubbedValue) {
+		t.Errorf("expected token to be replaced with %s, got: %s", scrubbedValue, contents)
+	}
+
+	// the fixture is replayed using a different token, without sending any request
+	recorded := calls
+	replay, err := newReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = newTestClient("sk-"+"othertoken", replay)
+
+	model, err := client.GetModel("gpt-4o")
+	if err != nil || model.Id != "gpt-4o" {
+		t.Errorf("got: %+v %v, want: %s", model, err, "gpt-4o")
+	}
+	for _, expected := range []string{"file_2", "file_3"} {
+		if id, err := client.UploadFile("main.py", strings.NewReader("print('main')")); err != nil || id != expected {
+			t.Errorf("got: %s %v, want: %s", id, err, expected)
+		}
+	}
+	var chatGPTError ChatGPTError
+	if _, err := client.GetAssistant("asst_missing"); !errors.As(err, &chatGPTError) || chatGPTError.Code != http.StatusNotFound {
+		t.Errorf("got: %v, want: %d", err, http.StatusNotFound)
+	}
+
+	if calls != recorded {
+		t.Errorf("got: %d requests, want: %d", calls, recorded)
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("expected every interaction to be replayed, got: %+v", unused)
+	}
+}
+
+// TestReplayMismatch tests that requests without a recorded response, including repeated
+// requests to an endpoint that was only recorded once, fail instead of being sent.
+func TestReplayMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	fixture := `{"interactions": [{"request": {"method": "GET", "url": "https://api.openai.com/v1/models"}, "response": {"status": 200, "body": "{}"}}]}`
+	if err := os.WriteFile(path, []byte(fixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+	replay, err := newReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient("sk-"+"replaytoken", replay)
+
+	if err := client.VerifyCredentials(); err != nil {
+		t.Fatal(err)
+	}
+
+	var mismatch ReplayMismatchError
+	for _, request := range []func() error{
+		client.VerifyCredentials,
+		func() error { _, err := client.GetModel("gpt-4o"); return err },
+	} {
+		if err := request(); !errors.As(err, &mismatch) {
+			t.Errorf("got: %v, want: %T", err, mismatch)
+		}
+	}
+	if mismatch.Method != http.MethodGet || mismatch.URL != APIUrl+"/models/gpt-4o" {
+		t.Errorf("got: %s %s, want: %s %s", mismatch.Method, mismatch.URL, http.MethodGet, APIUrl+"/models/gpt-4o")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ValidationModeOff  = "off"
+	ValidationModeWarn = "warn"
+	ValidationModeFail = "fail"
+)
+
+// Problem is a single issue found by a check in the generated README content.
+type Problem struct {
+	Check   string
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("[%s] line %d: %s", p.Check, p.Line, p.Message)
+	}
+	return fmt.Sprintf("[%s] %s", p.Check, p.Message)
+}
+
+// CheckOptions configures the checks run against a README.
+type CheckOptions struct {
+	Lint LintConfig
+	// URLChecker is used to check external links. external links are not checked if nil
+	URLChecker URLChecker
+	// Outline contains the sections the README must contain. sections are not checked if empty
+	Outline []OutlineSection
+}
+
+// ReadmeCheck validates the generated README content, returning the problems it finds.
+type ReadmeCheck struct {
+	Name string
+	// Guidance explains to the assistant how the problems of the check should be repaired
+	Guidance string
+	Run      func(content string) ([]Problem, error)
+}
+
+// newReadmeChecks creates the checks that are run against READMEs generated for the
+// target directory. checks that cannot be created are logged and skipped.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - files: The discovered files of the project, relative to the target directory.
+//   - options: The options of the optional and configurable checks.
+//
+// Returns:
+//   - []ReadmeCheck: The checks for the target project.
+func newReadmeChecks(target string, files []string, options CheckOptions) []ReadmeCheck {
+	checks := []ReadmeCheck{newLintCheck(options.Lint)}
+
+	if len(options.Outline) > 0 {
+		checks = append(checks, newSectionsCheck(options.Outline))
+	}
+
+	if options.URLChecker != nil {
+		checks = append(checks, newURLCheck(options.URLChecker))
+	}
+
+	if check, err := newReferenceCheck(target, files); err != nil {
+		log.Warn(fmt.Sprintf("error indexing source code for reference check: %+v", err))
+	} else {
+		checks = append(checks, check)
+	}
+
+	// go examples can only be compiled for go modules
+	if _, err := os.Stat(filepath.Join(target, "go.mod")); err == nil {
+		checks = append(checks, newExamplesCheck(target))
+	}
+	return checks
+}
+
+// relativePaths converts the paths of the discovered files to slash separated
+// paths relative to the target directory.
+func relativePaths[T any](target string, files map[string]T) []string {
+	paths := []string{}
+	for filename := range files {
+		if relative, err := filepath.Rel(target, filename); err == nil {
+			paths = append(paths, filepath.ToSlash(relative))
+		}
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// newReferenceCheck creates a check that reports file paths, CLI flags and identifiers
+// referenced in the README that do not exist in the target project.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - files: The discovered files of the project, relative to the target directory.
+//
+// Returns:
+//   - ReadmeCheck: The reference check.
+//   - error: An error if the symbol index of the project cannot be built.
+func newReferenceCheck(target string, files []string) (ReadmeCheck, error) {
+	index, err := buildSymbolIndex(target)
+	if err != nil {
+		return ReadmeCheck{}, err
+	}
+
+	binary := filepath.Base(target)
+	if absolute, err := filepath.Abs(target); err == nil {
+		binary = filepath.Base(absolute)
+	}
+
+	return ReadmeCheck{
+		Name:     "references",
+		Guidance: "References to files, flags or identifiers that do not exist in the attached source code must be removed or corrected.",
+		Run: func(content string) ([]Problem, error) {
+			problems := []Problem{}
+
+			references := extractReferences(content, binary)
+			for _, reference := range validateReferences(references, target, files, index) {
+				problems = append(problems, Problem{
+					Check:   "references",
+					Line:    reference.Line,
+					Message: fmt.Sprintf("unknown %s %s", reference.Kind, reference.Value),
+				})
+			}
+			return problems, nil
+		},
+	}, nil
+}
+
+// runReadmeChecks runs all of the provided checks against the README content.
+// checks that fail to run are logged and skipped.
+func runReadmeChecks(content string, checks []ReadmeCheck) []Problem {
+	problems := []Problem{}
+	for _, check := range checks {
+		found, err := check.Run(content)
+		if err != nil {
+			log.Warn(fmt.Sprintf("error running %s check: %+v", check.Name, err))
+			continue
+		}
+		problems = append(problems, found...)
+	}
+	return problems
+}
+
+// repairInstructions creates the message sent to the assistant asking it to fix
+// the problems found in the README it generated. the guidance of each check that
+// reported a problem is included in the message.
+func repairInstructions(problems []Problem, checks []ReadmeCheck) string {
+	var builder strings.Builder
+	builder.WriteString("The README you generated has the following problems:\n\n")
+
+	failed := map[string]bool{}
+	for _, problem := range problems {
+		builder.WriteString(fmt.Sprintf("- %s\n", problem))
+		failed[problem.Check] = true
+	}
+
+	builder.WriteString("\n")
+	for _, check := range checks {
+		if failed[check.Name] && len(check.Guidance) > 0 {
+			builder.WriteString(check.Guidance + "\n")
+		}
+	}
+	builder.WriteString("Please fix these problems and reply with the complete, corrected README only.")
+	return builder.String()
+}
+
+// reportProblems logs each of the problems found in the README as a warning.
+func reportProblems(problems []Problem) {
+	for _, problem := range problems {
+		log.Warn(problem.String())
+	}
+}
@@ -2,60 +2,97 @@ package main
 
 import (
 	"bufio"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/term"
 )
 
-const (
-	Query = `Please generate a README for the attached source code. All of the files for a
-given file extension have been combined into a single file called combined_source_files.[ext]
-where ext is the file extension. The combined file is organized into a set of file blocks,
-where each block starts with
-
-### FILE START [filepath]
-
-and ends with
-
-### FILE END [filepath]
-
-where [filepath] gives the path of the original source code file. Treat the code within
-each file block as a separate file for the purposes of the README. Some files that have extensions
-that are not supported for ChatGPT retrieval (such as .vue files) are combined into a .[ext].txt file.
-
-Please do not include any references to the combined_source_files.[ext] file containing the
-combined source code. Only reference the original source code files using the file names provided.
-Ensure that context is provided that explains the purpose of the code and how it can be used
-where possible.`
-)
-
+// ConfigureCLICommand creates the goreadme config file. Values provided using flags are
+// used as is, and the user is prompted for the remaining values, with values provided using
+// environment variables used as the defaults of the prompts. The command runs without reading
+// stdin if the access token is provided using --token or --non-interactive is set, in which
+// case values provided using environment variables are used as is. Vector stores and
+// assistants are created if their ID is left empty when prompted, or if --create-missing is
+// set when running non-interactively.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if any of the values cannot be validated or the config cannot be written, otherwise nil.
 func ConfigureCLICommand(ctx context.Context, cmd *cli.Command) error {
 	// configure logging for application
 	configureLogging(cmd.String("log-level"))
 	reader := bufio.NewReader(os.Stdin)
 
-	var client *ChatGPTAssistantClient
-	// prompt user for ChatGPT access token
-	token, err := getCliInput(reader, "Enter ChatGPT access token: ", func(value string) (string, error) {
-		credentials := ChatGPTCredentials{
-			Secret: value,
+	token := cmd.String("token")
+	if len(token) == 0 {
+		token = cmd.String("access-token")
+	}
+	flags := ConfigOverrides{
+		AccessToken:   token,
+		ModelVersion:  cmd.String("model"),
+		AssistantId:   cmd.String("assistant-id"),
+		VectorStoreId: cmd.String("vector-store-id"),
+	}
+	values := configOverridesFromEnv(os.LookupEnv).Merge(flags)
+
+	// tokens stored as a reference to an environment variable or credential
+	// helper are read from the reference if no token is provided
+	store := cmd.String("token-store")
+	if len(values.AccessToken) == 0 && isSecretReference(store) {
+		secret, err := resolveSecret(store, newSecretSources(cmd.String("config-path")))
+		if err != nil {
+			log.Debug(fmt.Sprintf("error resolving access token: %+v", err))
+			return cli.Exit(fmt.Sprintf("error resolving access token from %s", store), 1)
 		}
-		client = NewChatGPTAssistantClient("", credentials)
+		values.AccessToken = secret
+	}
 
-		// verify provided credentials using client
-		if err := client.VerifyCredentials(); err != nil {
-			log.Debug(fmt.Sprintf("error validating chatgpt token: %+v", err))
-			return "", err
-		} else {
-			return value, nil
+	// prompt for values unless explicitly running non-interactively. tokens found in
+	// the environment are only offered as the default of the prompt
+	interactive := !cmd.IsSet("token") && !cmd.Bool("non-interactive")
+	create := cmd.Bool("create-missing")
+
+	// input uses the value of the flag if there is one, and otherwise prompts the
+	// user for a value if running interactively, using value if the prompt is left empty
+	input := func(prompt, flag, value string, action func(value string) (string, error)) (string, error) {
+		if !interactive || len(flag) > 0 {
+			return action(value)
 		}
+		return getCliInput(reader, prompt, func(answer string) (string, error) {
+			if len(strings.TrimSpace(answer)) == 0 {
+				answer = value
+			}
+			return action(answer)
+		})
+	}
+
+	var client *ChatGPTAssistantClient
+	// prompt user for ChatGPT access token
+	tokenPrompt := "Enter ChatGPT access token: "
+	if len(values.AccessToken) > 0 {
+		tokenPrompt = fmt.Sprintf("Enter ChatGPT access token (default %s): ", maskSecret(values.AccessToken))
+	}
+	token, err := input(tokenPrompt, flags.AccessToken, values.AccessToken, func(value string) (string, error) {
+		var err error
+		client, err = verifyToken(value)
+		return value, err
 	})
 
 	if err != nil {
@@ -64,17 +101,8 @@ func ConfigureCLICommand(ctx context.Context, cmd *cli.Command) error {
 
 	// get model version from CLI and validate by making request to ChatGPT
 	// api to get model details using specified ID
-	model, err := getCliInput(reader, "Enter ChatGPT model version (default gpt-4o-mini): ", func(value string) (string, error) {
-		if len(value) == 0 {
-			value = "gpt-4o-mini"
-		}
-
-		if _, err := client.GetModel(value); err != nil {
-			log.Debug(fmt.Sprintf("error validating chatgpt model: %+v", err))
-			return "", err
-		} else {
-			return value, nil
-		}
+	model, err := input(fmt.Sprintf("Enter ChatGPT model version (default %s): ", cmp.Or(values.ModelVersion, DefaultModel)), flags.ModelVersion, values.ModelVersion, func(value string) (string, error) {
+		return verifyModel(client, value)
 	})
 
 	if err != nil {
@@ -82,111 +110,115 @@ func ConfigureCLICommand(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	client.Model = model
+	result := ConfigureResult{
+		ModelVersion: model,
+		Created:      map[string]string{},
+	}
 
 	// get vector store ID from CLI and validate by making request to ChatGPT
 	// api to get vector store details using specified ID. if no ID is provided,
 	// create a new vector store and use the generated ID
-	vectorStoreId, err := getCliInput(reader, "Enter ChatGPT vector store ID (leave empty to create vector store): ", func(value string) (string, error) {
-		if len(value) == 0 {
-			id, err := client.CreateVectorStore("goreadme")
-			if err != nil {
-				log.Debug(fmt.Sprintf("error creating chatgpt vector store: %+v", err))
-				chatGPTError := err.(ChatGPTError)
-				log.Debug(fmt.Sprintf("error response: %+v", chatGPTError.Body))
-				return "", err
-			}
-			return id, nil
-		}
-
-		if _, err := client.GetVectorStore(value); err != nil {
-			log.Debug(fmt.Sprintf("error validating chatgpt vector store: %+v", err))
-			return "", err
-		} else {
-			return value, nil
+	vectorStorePrompt := "Enter ChatGPT vector store ID (leave empty to create vector store): "
+	if len(values.VectorStoreId) > 0 {
+		vectorStorePrompt = fmt.Sprintf("Enter ChatGPT vector store ID (default %s): ", values.VectorStoreId)
+	}
+	vectorStoreId, err := input(vectorStorePrompt, flags.VectorStoreId, values.VectorStoreId, func(value string) (string, error) {
+		id, created, err := resolveVectorStore(client, value, create || interactive)
+		if created {
+			result.Created["vectorStoreId"] = id
 		}
+		return id, err
 	})
 
 	if err != nil {
+		log.Debug(fmt.Sprintf("error creating/validating vector store: %+v", err))
 		return cli.Exit("error creating/validating vector store", 1)
 	}
 
 	// get assistant ID from CLI and validate by making request to ChatGPT
 	// api to get assistant details using specified ID. if no ID is provided,
 	// create a new assistant and use the generated ID
-	assistantId, err := getCliInput(reader, "Enter ChatGPT assistant ID (leave empty to create assistant): ", func(value string) (string, error) {
-		if len(value) == 0 {
-			description := "You are an assistant for auto-generating READMEs and associated documentation."
-			id, err := client.CreateAssistant("goreadme", description, model, vectorStoreId)
-			if err != nil {
-				log.Debug(fmt.Sprintf("error creating chatgpt assistant: %+v", err))
-				chatGPTError := err.(ChatGPTError)
-				log.Debug(fmt.Sprintf("error response: %+v", chatGPTError.Body))
-				return "", err
-			}
-			return id, nil
-		}
-
-		assistant, err := client.GetAssistant(value)
-		if err != nil {
-			log.Debug(fmt.Sprintf("error validating chatgpt assistant: %+v", err))
-			return "", err
-		}
-
-		if assistant.ToolResources.FileSearch.VectorStoreIds == nil {
-			log.Debug("vector store ids not found in assistant tool resources")
-			return "", fmt.Errorf("vector store ids not found in assistant tool resources")
-		}
-
-		if len(assistant.ToolResources.FileSearch.VectorStoreIds) == 0 {
-			log.Debug("vector store ids not found in assistant tool resources")
-			return "", fmt.Errorf("vector store ids not found in assistant tool resources")
+	assistantPrompt := "Enter ChatGPT assistant ID (leave empty to create assistant): "
+	if len(values.AssistantId) > 0 {
+		assistantPrompt = fmt.Sprintf("Enter ChatGPT assistant ID (default %s): ", values.AssistantId)
+	}
+	assistantId, err := input(assistantPrompt, flags.AssistantId, values.AssistantId, func(value string) (string, error) {
+		id, created, err := resolveAssistant(client, value, model, vectorStoreId, create || interactive)
+		if created {
+			result.Created["assistantId"] = id
 		}
-
-		return value, nil
+		return id, err
 	})
 
 	if err != nil {
+		log.Debug(fmt.Sprintf("error creating/validating assistant: %+v", err))
 		return cli.Exit("error creating/validating assistant", 1)
 	}
 
-	defaultConfigPath := getDefaultConfigPath()
-	prompt := fmt.Sprintf("Enter config path (default %s): ", defaultConfigPath)
-	// read token from input and remove trailing line break. if no
-	// path is provided, use default
-	path, _ := getCliInput(reader, prompt, func(value string) (string, error) {
-		return value, nil
-	})
+	path := cmd.String("config-path")
+	if interactive && !cmd.IsSet("config-path") {
+		defaultConfigPath := getDefaultConfigPath()
+		prompt := fmt.Sprintf("Enter config path (default %s): ", defaultConfigPath)
+		// read token from input and remove trailing line break. if no
+		// path is provided, use default
+		path, _ = getCliInput(reader, prompt, func(value string) (string, error) {
+			return value, nil
+		})
+
+		if len(path) == 0 {
+			// get home directory and generate path
+			path = defaultConfigPath
+		}
+	}
 
-	if len(path) == 0 {
-		// get home directory and generate path
-		path = defaultConfigPath
+	storedToken, err := storeAccessToken(token, store, path, profileFromCommand(cmd))
+	if err != nil {
+		log.Debug(fmt.Sprintf("error storing access token: %+v", err))
+		return cli.Exit(fmt.Sprintf("error storing access token: %s", err), 1)
 	}
 
 	config := Config{
-		AccessToken:   token,
+		AccessToken:   storedToken,
 		ModelVersion:  model,
 		VectorStoreId: vectorStoreId,
 		AssistantId:   assistantId,
 	}
 
-	if err := writeConfig(config, path); err != nil {
+	if err := writeConfig(config, path, profileFromCommand(cmd)); err != nil {
 		log.Debug(fmt.Sprintf("%+v", err))
 		return cli.Exit(fmt.Sprintf("error writing config file to %s", path), 1)
 	}
 
+	if cmd.Bool("json") {
+		result.ConfigPath = path
+		if file, err := readConfigFile(path); err == nil {
+			result.Profile = file.Profile(profileFromCommand(cmd))
+		}
+		result.VectorStoreId = vectorStoreId
+		result.AssistantId = assistantId
+
+		encoded, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return cli.Exit("error encoding configure result", 1)
+		}
+		fmt.Println(string(encoded))
+	}
+
 	return nil
 }
 
 // TestCLICommand is a function that tests a CLI command by loading and applying a configuration.
-// It takes a context and a CLI command as parameters and returns an error if the configuration
-// cannot be loaded.
+// Every profile of the config file is checked, unless a profile is selected using --profile
+// or GOREADME_PROFILE, in which case only the selected profile is checked. Each check of the
+// config file, token, model, vector store, assistant and file uploads is reported with its
+// result and reason, as text or as JSON when using --json.
 //
 // Parameters:
 //   - ctx: The context in which the command is executed.
 //   - cmd: The CLI command to be tested.
 //
 // Returns:
-//   - error: An error if the configuration cannot be loaded, otherwise nil.
+//   - error: An error if any check of any profile failed, otherwise nil.
 func TestCLICommand(ctx context.Context, cmd *cli.Command) error {
 	// configure logging for application
 	configureLogging(cmd.String("log-level"))
@@ -194,39 +226,38 @@ func TestCLICommand(ctx context.Context, cmd *cli.Command) error {
 	cfgPath := cmd.String("config-path")
 	log.Debug(fmt.Sprintf("loading new configuration from path %s", cfgPath))
 
-	config, err := loadConfig(cfgPath)
-	if err != nil {
-		return cli.Exit("error loading config file", 1)
-	}
-	log.Debug(fmt.Sprintf("loaded configuration %+v", config))
-
-	client := NewChatGPTAssistantClient(config.ModelVersion, ChatGPTCredentials{
-		Secret: config.AccessToken,
-	})
-
-	if err := client.VerifyCredentials(); err != nil {
-		log.Debug(fmt.Sprintf("error verifying chatgpt credentials: %+v", err))
-		return cli.Exit("error validating chatgpt credentials", 1)
+	// test the profile selected, or the profile provided by overrides if
+	// there is no config file. otherwise test all of the profiles
+	profiles := []string{profileFromCommand(cmd)}
+	if file, err := readConfigFile(cfgPath); err == nil && len(profiles[0]) == 0 && len(file.Profiles) > 0 {
+		profiles = file.ProfileNames()
 	}
 
-	_, err = client.GetModel(config.ModelVersion)
-	if err != nil {
-		log.Debug(fmt.Sprintf("error fetching model %s from chatgpt api: %+v", config.ModelVersion, err))
-		return cli.Exit("error validating chatgpt model", 1)
+	reports := []DoctorReport{}
+	failed := 0
+	for _, profile := range profiles {
+		report := runDiagnostics(cfgPath, profile, configOverridesFromCommand(cmd))
+		if !report.Passed {
+			failed++
+		}
+		reports = append(reports, report)
 	}
 
-	_, err = client.GetVectorStore(config.VectorStoreId)
-	if err != nil {
-		log.Debug(fmt.Sprintf("error fetching vector store %s from chatgpt api: %+v", config.VectorStoreId, err))
-		return cli.Exit("error validating chatgpt vector store", 1)
+	if cmd.Bool("json") {
+		encoded, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return cli.Exit("error encoding test results", 1)
+		}
+		fmt.Println(string(encoded))
+	} else {
+		for _, report := range reports {
+			fmt.Print(report)
+		}
 	}
 
-	_, err = client.GetAssistant(config.AssistantId)
-	if err != nil {
-		log.Debug(fmt.Sprintf("error fetching assistant %s from chatgpt api: %+v", config.AssistantId, err))
-		return cli.Exit("error validating chatgpt assistant", 1)
+	if failed > 0 {
+		return cli.Exit(fmt.Sprintf("%d of %d profiles failed validation", failed, len(profiles)), 1)
 	}
-
 	return nil
 }
 
@@ -253,15 +284,20 @@ func GenerateCLICommand(ctx context.Context, cmd *cli.Command) error {
 	cfgPath := cmd.String("config-path")
 	log.Debug(fmt.Sprintf("loading new configuration from path %s", cfgPath))
 
-	config, err := loadConfig(cfgPath)
+	config, err := loadClientConfigFromCommand(cmd)
 	if err != nil {
-		return cli.Exit("error loading config file", 1)
+		return cli.Exit(err.Error(), 1)
 	}
 	log.Debug(fmt.Sprintf("loaded configuration %+v", config))
 
 	target := cmd.String("target")
 	log.Debug(fmt.Sprintf("generating new README for target dir %s", target))
 
+	mode := cmd.String("validate")
+	if !slices.Contains([]string{ValidationModeOff, ValidationModeWarn, ValidationModeFail}, mode) {
+		return cli.Exit(fmt.Sprintf("invalid validation mode %s: expected off, warn or fail", mode), 1)
+	}
+
 	spinner.Prefix = "Validating target directory "
 	// check that provided path is a valid directory
 	if !isValidDir(target) {
@@ -269,74 +305,178 @@ func GenerateCLICommand(ctx context.Context, cmd *cli.Command) error {
 	}
 
 	spinner.Prefix = "Checking CLI inputs and config settings "
+	settings, err := resolveSettings(cmd, target, os.LookupEnv)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error resolving settings: %+v", err))
+		return cli.Exit(fmt.Sprintf("error resolving settings: %s", err), 1)
+	}
+	log.Debug(fmt.Sprintf("resolved settings %+v", settings))
+	outline := settings.Sections
+
+	// the run report is printed once the run has finished, including runs that fail
+	report := &RunReport{}
+	defer func() {
+		spinner.Stop()
+		fmt.Print(report)
+	}()
 
 	// get all files that need to be uploaded and group
 	// by file extension/type.
-	files, err := getFilesToUpload(target)
+	files, skipped, err := getFilesToUpload(target, settings.DiscoveryOptions())
 	if err != nil {
 		log.Debug(fmt.Sprintf("error reading source code files: %+v", err))
 		return cli.Exit("error generating README", 1)
 	}
+	report.Skipped = skipped
+
+	files, err = settings.filterFiles(target, files)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("error generating README: %s", err), 1)
+	}
+
+	// secrets are found before any file is combined, so they never leave the machine
+	if settings.Secrets != SecretsModeOff {
+		spinner.Prefix = "Scanning files for secrets "
+		rules, err := newSecretRules(settings.SecretPatterns)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error generating README: %s", err), 1)
+		}
+
+		files, report.Secrets, err = scanSecrets(files, rules, settings.Secrets)
+		report.SecretsRedacted = settings.Secrets == SecretsModeRedact
+		var secretsFound SecretsFoundError
+		if errors.As(err, &secretsFound) {
+			return cli.Exit(fmt.Sprintf("%s, no files were uploaded. use --secrets redact to redact them", err), 1)
+		} else if err != nil {
+			log.Debug(fmt.Sprintf("error scanning files for secrets: %+v", err))
+			return cli.Exit(fmt.Sprintf("error generating README: %s", err), 1)
+		}
+	}
+
+	// the files that leave the machine are listed for approval before anything is uploaded
+	if !cmd.Bool("yes") {
+		spinner.Stop()
+		files, err = approveUploadsFromCommand(target, files, report.Secrets)
+		if err != nil {
+			return err
+		}
+		spinner.Start()
+	}
 
 	log.Debug(fmt.Sprintf("found %d files to upload", len(files)))
 	grouped := groupFilesByExtension(files)
 
 	toUpload := map[string]io.Reader{}
-	// combine all files of the same type into a single file
+	sources := map[string]map[string]SourceFile{}
+	// combine all files of the same type into a single file. the contents are
+	// streamed into the upload, and the files of each combined file are kept
+	// so that citations can be resolved to source files
 	log.Debug(fmt.Sprintf("found %d unique file extensions", len(grouped)))
 	for ext, files := range grouped {
-		combined := combineFiles(files)
-		log.Debug(fmt.Sprintf("combined %d files of type %s", len(files), ext))
-		filename := "combined_source_files" + ext
-		toUpload[filename] = combined
+		log.Debug(fmt.Sprintf("combining %d files of type %s", len(files), ext))
+		filename := combinedFilename(ext)
+		sources[filename] = files
+		toUpload[filename] = combineFiles(files)
 	}
 
 	spinner.Prefix = fmt.Sprintf("Analyzing %d files", len(toUpload))
 	// upload files to ChatGPT assistant
-	client := NewChatGPTAssistantClient(config.ModelVersion, ChatGPTCredentials{
+	client := NewChatGPTAssistantClient(settings.Model, ChatGPTCredentials{
 		Secret: config.AccessToken,
 	})
 
-	spinner.Prefix = fmt.Sprintf("Uploading %d files to ChatGPT assistant", len(toUpload))
-	fileIds, errors := uploadFiles(client, toUpload)
+	if cmd.Bool("sync-instructions") {
+		spinner.Prefix = "Syncing assistant instructions "
+		text, source, err := loadPromptTemplateFromCommand(cmd, target, settings)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error loading prompt template %s: %s", source, err), 1)
+		}
+		instructions, err := assistantInstructions(text)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error rendering prompt template %s: %s", source, err), 1)
+		}
+		if _, err := syncAssistantInstructions(client, config.AssistantId, instructions); err != nil {
+			log.Debug(fmt.Sprintf("error syncing assistant instructions: %+v", err))
+			return cli.Exit(fmt.Sprintf("error syncing assistant instructions: %s", chatGPTErrorReason(err)), 1)
+		}
+	}
+
+	spinner.Prefix = fmt.Sprintf("Uploading %d files to ChatGPT assistant ", len(toUpload))
+	uploads := uploadFiles(ctx, client, toUpload, settings.Concurrency, spinnerProgress(spinner, "Uploaded"))
 
-	if len(errors) > 0 {
-		for _, e := range errors {
-			log.Debug(fmt.Sprintf("error uploading file: %+v", e))
-			chatGPTError := e.(ChatGPTError)
-			log.Debug(fmt.Sprintf("error response: %+v", chatGPTError.Body))
+	// the upload is abandoned if any file fails, deleting the files that were uploaded
+	if err := uploads.Err(); err != nil {
+		for _, result := range uploads.Failed() {
+			log.Debug(fmt.Sprintf("error uploading file %s: %+v", result.Name, result.Err))
 		}
-		log.Debug(fmt.Sprintf("found %d errors during file upload", len(errors)))
-		return cli.Exit("error generating README", 1)
+		deleteFiles(ctx, client, uploads.FileIds(), settings.Concurrency, nil)
+		return cli.Exit(fmt.Sprintf("error uploading %d of %d files: %s", len(uploads.Failed()), len(toUpload), err), 1)
 	}
+	fileIds := uploads.FileIds()
+
+	// uploaded files are deleted on every return, unless they were already deleted after
+	// the README was written. the files are deleted even if the command was cancelled
+	filesDeleted := false
+	defer func() {
+		if filesDeleted {
+			return
+		}
+		deletions := deleteFiles(context.WithoutCancel(ctx), client, fileIds, settings.Concurrency, nil)
+		for _, result := range deletions.Failed() {
+			log.Warn(fmt.Sprintf("uploaded file %s could not be deleted: %+v", result.Name, result.Err))
+		}
+	}()
 
+	// files are either added to a vector store created for this run, or
+	// attached to the message and searched using the configured vector store
+	vectorStoreId := config.VectorStoreId
 	attachments := []FileAttachment{}
-	for _, id := range fileIds {
-		attachments = append(attachments, FileAttachment{
-			FileId: id,
-			Tools: []Tool{
-				{
-					Type: "file_search",
+	if cmd.Bool("run-vector-store") {
+		spinner.Prefix = fmt.Sprintf("Indexing %d files in run vector store ", len(fileIds))
+		vectorStoreId, err = createRunVectorStore(client, fileIds)
+		if len(vectorStoreId) > 0 {
+			defer deleteRunVectorStore(client, vectorStoreId)
+		}
+		if err != nil {
+			log.Debug(fmt.Sprintf("error creating run vector store: %+v", err))
+			return cli.Exit(fmt.Sprintf("error indexing files: %s", chatGPTErrorReason(err)), 1)
+		}
+	} else {
+		for _, id := range fileIds {
+			attachments = append(attachments, FileAttachment{
+				FileId: id,
+				Tools: []Tool{
+					{
+						Type: "file_search",
+					},
 				},
-			},
-		})
+			})
+		}
+	}
+
+	prompt, source, err := renderPromptFromCommand(cmd, target, files, settings)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error rendering prompt template %s: %+v", source, err))
+		return cli.Exit(fmt.Sprintf("error rendering prompt template %s: %s", source, err), 1)
 	}
+	log.Debug(fmt.Sprintf("using prompt template %s", source))
 
 	messages := []ThreadMessage{
 		{
 			Role:        "user",
-			Content:     Query,
+			Content:     prompt,
 			Attachments: attachments,
 		},
 	}
 
 	spinner.Prefix = "Generating README using ChatGPT assistant "
-	run, err := client.CreateThreadAndRun(config.AssistantId, config.VectorStoreId, messages)
+	run, err := client.CreateThreadAndRun(config.AssistantId, vectorStoreId, messages)
 	if err != nil {
 		log.Debug(fmt.Sprintf("error creating thread and run: %+v", err))
-		chatGPTError := err.(ChatGPTError)
-		log.Debug(fmt.Sprintf("error creating thread: %+v", chatGPTError.Body))
-		return cli.Exit("error generating README", 1)
+		if chatGPTError, ok := err.(ChatGPTError); ok {
+			log.Debug(fmt.Sprintf("error creating thread: %+v", chatGPTError.Body))
+		}
+		return cli.Exit(fmt.Sprintf("error generating README: %s", chatGPTErrorReason(err)), 1)
 	}
 
 	result, err := client.WaitForRunCompletion(run.ThreadId, run.Id)
@@ -352,13 +492,78 @@ func GenerateCLICommand(ctx context.Context, cmd *cli.Command) error {
 	threadMessages, err := client.GetThreadMessages(run.ThreadId)
 	if err != nil {
 		log.Debug(fmt.Sprintf("error retrieving messages: %+v", err))
-		chatGPTError := err.(ChatGPTError)
-		log.Debug(fmt.Sprintf("error creating thread: %+v", chatGPTError.Body))
-		return cli.Exit("error generating README", 1)
+		if chatGPTError, ok := err.(ChatGPTError); ok {
+			log.Debug(fmt.Sprintf("error retrieving messages: %+v", chatGPTError.Body))
+		}
+		return cli.Exit(fmt.Sprintf("error generating README: %s", chatGPTErrorReason(err)), 1)
+	}
+
+	resolver := &CitationResolver{
+		Root:    target,
+		Sources: sources,
+		Filename: func(fileId string) (string, error) {
+			file, err := client.GetFile(fileId)
+			return file.Filename, err
+		},
+	}
+
+	content, err := extractAssistantOutput(threadMessages, run.ThreadId, run.Id, resolver)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error extracting README content: %+v", err))
+		return cli.Exit(fmt.Sprintf("error generating README: %s", err), 1)
+	}
+
+	output := settings.OutputPath(target)
+	// relative links are resolved from the directory containing the README
+	lint := lintConfigFromCommand(cmd, filepath.Dir(output))
+	// clean up and optionally fix the assistant output before
+	// it is validated and written
+	prepare := func(content string) string {
+		if !cmd.Bool("raw") {
+			content = postProcessOutput(content, defaultPostProcessors...)
+		}
+		if cmd.Bool("fix") {
+			content = fixMarkdown(content, lint)
+		}
+		return content
 	}
+	content = prepare(content)
+
+	problems := []Problem{}
+	if mode != ValidationModeOff {
+		spinner.Prefix = "Validating README content "
+		options := checkOptionsFromCommand(cmd, lint)
+		options.Outline = outline
+		checks := newReadmeChecks(target, relativePaths(target, files), options)
+
+		// only the missing sections are requested from the assistant, and
+		// are inserted into the README in the position given by the outline
+		if missing := findMissingSections(content, outline); len(missing) > 0 {
+			spinner.Prefix = fmt.Sprintf("Generating %d missing sections using ChatGPT assistant ", len(missing))
+			log.Debug(fmt.Sprintf("requesting %d missing sections", len(missing)))
+			generated, err := requestRevision(client, config.AssistantId, run.ThreadId, missingSectionsInstructions(missing), resolver)
+			if err != nil {
+				log.Warn(fmt.Sprintf("error generating missing sections: %+v", err))
+			} else {
+				content, _ = insertSections(content, generated, outline)
+				content = prepare(content)
+			}
+		}
 
-	content := threadMessages[0].Content[0].Text.Value
-	output := filepath.Join(target, "README.md")
+		problems = runReadmeChecks(content, checks)
+		if len(problems) > 0 && cmd.Bool("repair") {
+			spinner.Prefix = "Repairing README content using ChatGPT assistant "
+			log.Debug(fmt.Sprintf("requesting repair of %d problems", len(problems)))
+			revised, err := requestRevision(client, config.AssistantId, run.ThreadId, repairInstructions(problems, checks), resolver)
+			if err != nil {
+				log.Warn(fmt.Sprintf("error repairing README: %+v", err))
+			} else {
+				content = prepare(revised)
+				problems = runReadmeChecks(content, checks)
+			}
+		}
+		reportProblems(problems)
+	}
 
 	spinner.Prefix = "Writing README content to file "
 	file, err := os.Create(output)
@@ -373,17 +578,803 @@ func GenerateCLICommand(ctx context.Context, cmd *cli.Command) error {
 		return cli.Exit("error generating README", 1)
 	}
 
+	// the README has been written, so files that cannot be deleted are reported
+	// with their IDs so they can be deleted later
 	spinner.Prefix = "Deleting files from assistant "
-	deleteErrors := deleteFiles(client, fileIds)
-	if len(deleteErrors) > 0 {
-		for _, e := range deleteErrors {
-			log.Debug(fmt.Sprintf("error deleting file: %+v", e))
-			chatGPTError := e.(ChatGPTError)
-			log.Debug(fmt.Sprintf("error response: %+v", chatGPTError.Body))
-		}
-		log.Debug(fmt.Sprintf("found %d errors during file deletion", len(errors)))
-		return cli.Exit("error generating README", 1)
+	deletions := deleteFiles(ctx, client, fileIds, settings.Concurrency, spinnerProgress(spinner, "Deleted"))
+	filesDeleted = true
+	if err := deletions.Err(); err != nil {
+		for _, result := range deletions.Failed() {
+			log.Debug(fmt.Sprintf("error deleting file %s: %+v", result.Name, result.Err))
+		}
+		return cli.Exit(fmt.Sprintf("README written to %s, but %d uploaded files could not be deleted: %s", output, len(deletions.Failed()), err), 1)
+	}
+
+	if mode == ValidationModeFail && len(problems) > 0 {
+		return cli.Exit(fmt.Sprintf("README written to %s failed validation with %d problems", output, len(problems)), 1)
+	}
+
+	return nil
+}
+
+// approveUploadsFromCommand shows the upload manifest and asks the user to approve it,
+// removing deselected files from the files to upload and saving them as excludes of the
+// project config if requested.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - files: The files to upload, keyed by the name they are uploaded with.
+//   - findings: The secrets that are redacted from the files.
+//
+// Returns:
+//   - map[string]SourceFile: The approved files.
+//   - error: A cli.Exit error if stdin is not a terminal, the upload is not approved or
+//     the excludes cannot be saved.
+func approveUploadsFromCommand(target string, files map[string]SourceFile, findings []SecretFinding) (map[string]SourceFile, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return files, cli.Exit("uploads must be approved when not running in a terminal, use --yes to upload without approval", 1)
+	}
+
+	approval, err := approveUploads(bufio.NewReader(os.Stdin), os.Stdout, buildManifest(target, files, findings))
+	if err != nil {
+		log.Debug(fmt.Sprintf("error reading upload approval: %+v", err))
+		return files, cli.Exit("error reading upload approval", 1)
+	}
+
+	approved := maps.Clone(files)
+	for _, entry := range approval.Deselected {
+		delete(approved, entry.Name)
+	}
+	patterns := excludePatterns(approval.Deselected)
+
+	if approval.SaveExcludes {
+		if err := addProjectExcludes(target, patterns); err != nil {
+			log.Debug(fmt.Sprintf("error saving excludes to project config: %+v", err))
+			return files, cli.Exit(fmt.Sprintf("error saving excludes to %s: %s", filepath.Join(target, ProjectConfigName), err), 1)
+		}
+		log.Info(fmt.Sprintf("added %d excludes to %s", len(patterns), filepath.Join(target, ProjectConfigName)))
+	}
+
+	if !approval.Approved {
+		return approved, cli.Exit("upload was not approved, no files were uploaded", 1)
+	}
+	return approved, nil
+}
+
+// CheckCLICommand validates an existing README using the same checks that are run
+// against generated READMEs, without uploading any files to ChatGPT. Go code examples
+// are compiled for Go modules. The command exits with a non-zero status if any problems
+// are found, so it can be used in CI.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the README cannot be read or fails validation, otherwise nil.
+func CheckCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	target := cmd.String("target")
+	if !isValidDir(target) {
+		return cli.Exit(fmt.Sprintf("path %s either does not exist or is not a valid directory", target), 1)
+	}
+
+	settings, err := resolveSettings(cmd, target, os.LookupEnv)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("error resolving settings: %s", err), 1)
+	}
+
+	readme := cmd.String("file")
+	if len(readme) == 0 {
+		readme = settings.OutputPath(target)
+	}
+
+	content, err := os.ReadFile(readme)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error reading README %s: %+v", readme, err))
+		return cli.Exit(fmt.Sprintf("error reading README %s", readme), 1)
+	}
+
+	files, skipped, err := getFilesToUpload(target, settings.DiscoveryOptions())
+	if err != nil {
+		log.Debug(fmt.Sprintf("error reading source code files: %+v", err))
+		return cli.Exit("error checking README", 1)
+	}
+	for _, path := range skipped {
+		log.Debug(fmt.Sprintf("skipped %s", path))
 	}
 
+	// relative links are resolved from the directory containing the README
+	lint := lintConfigFromCommand(cmd, filepath.Dir(readme))
+	if cmd.Bool("fix") {
+		fixed := fixMarkdown(string(content), lint)
+		if err := os.WriteFile(readme, []byte(fixed), 0644); err != nil {
+			log.Debug(fmt.Sprintf("error writing fixed README %s: %+v", readme, err))
+			return cli.Exit(fmt.Sprintf("error writing README %s", readme), 1)
+		}
+		content = []byte(fixed)
+	}
+
+	options := checkOptionsFromCommand(cmd, lint)
+	options.Outline = settings.Sections
+	checks := newReadmeChecks(target, relativePaths(target, files), options)
+	problems := runReadmeChecks(string(content), checks)
+	reportProblems(problems)
+
+	if len(problems) > 0 {
+		return cli.Exit(fmt.Sprintf("README %s failed validation with %d problems", readme, len(problems)), 1)
+	}
+	return nil
+}
+
+// loadConfigFromCommand loads the selected profile of the config file from the config
+// path of the provided command, applying the overrides of configOverridesFromCommand.
+func loadConfigFromCommand(cmd *cli.Command) (Config, error) {
+	return loadConfig(cmd.String("config-path"), profileFromCommand(cmd), configOverridesFromCommand(cmd))
+}
+
+// loadClientConfigFromCommand loads the config of the provided command like
+// loadConfigFromCommand, and resolves its access token so it can be used to make
+// requests to the ChatGPT API.
+func loadClientConfigFromCommand(cmd *cli.Command) (Config, error) {
+	config, err := loadConfigFromCommand(cmd)
+	if err != nil {
+		return config, err
+	}
+	return resolveAccessToken(config, cmd.String("config-path"))
+}
+
+// configOverridesFromCommand returns the config overrides of the environment variables and
+// the config flags of the provided command. flags take precedence over environment variables.
+func configOverridesFromCommand(cmd *cli.Command) ConfigOverrides {
+	flags := ConfigOverrides{}
+	if cmd.IsSet("access-token") {
+		flags.AccessToken = cmd.String("access-token")
+	}
+	if cmd.IsSet("model") {
+		flags.ModelVersion = cmd.String("model")
+	}
+	if cmd.IsSet("assistant-id") {
+		flags.AssistantId = cmd.String("assistant-id")
+	}
+	if cmd.IsSet("vector-store-id") {
+		flags.VectorStoreId = cmd.String("vector-store-id")
+	}
+	return configOverridesFromEnv(os.LookupEnv).Merge(flags)
+}
+
+// profileFromCommand returns the name of the config profile selected using the profile
+// flag of the provided command or the GOREADME_PROFILE environment variable. An empty
+// string is returned if no profile is selected, so the default profile is used.
+func profileFromCommand(cmd *cli.Command) string {
+	if profile := cmd.String("profile"); len(profile) > 0 {
+		return profile
+	}
+	return os.Getenv("GOREADME_PROFILE")
+}
+
+// lintConfigFromCommand creates the markdown lint config from the
+// lint flags of the provided command.
+func lintConfigFromCommand(cmd *cli.Command, target string) LintConfig {
+	return LintConfig{
+		Root:          target,
+		Disabled:      cmd.StringSlice("disable-lint"),
+		MaxLineLength: int(cmd.Int("max-line-length")),
+	}
+}
+
+// checkOptionsFromCommand creates the README check options from the flags
+// of the provided command. external links are only checked if enabled.
+func checkOptionsFromCommand(cmd *cli.Command, lint LintConfig) CheckOptions {
+	options := CheckOptions{
+		Lint: lint,
+	}
+	if cmd.Bool("check-urls") {
+		options.URLChecker = NewHTTPURLChecker(cmd.Duration("url-timeout"))
+	}
+	return options
+}
+
+// PromptShowCLICommand renders the prompt that would be sent to the assistant for
+// the target directory and prints it to stdout. The location of the template used
+// is printed to stderr.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the source files cannot be read or the template cannot be rendered, otherwise nil.
+func PromptShowCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	target := cmd.String("target")
+	if !isValidDir(target) {
+		return cli.Exit(fmt.Sprintf("path %s either does not exist or is not a valid directory", target), 1)
+	}
+
+	settings, err := resolveSettings(cmd, target, os.LookupEnv)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("error resolving settings: %s", err), 1)
+	}
+
+	files, skipped, err := getFilesToUpload(target, settings.DiscoveryOptions())
+	if err != nil {
+		log.Debug(fmt.Sprintf("error reading source code files: %+v", err))
+		return cli.Exit("error rendering prompt", 1)
+	}
+	for _, path := range skipped {
+		log.Debug(fmt.Sprintf("skipped %s", path))
+	}
+
+	files, err = settings.filterFiles(target, files)
+	if err != nil {
+		log.Warn(err.Error())
+	}
+
+	prompt, source, err := renderPromptFromCommand(cmd, target, files, settings)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("error rendering prompt template %s: %s", source, err), 1)
+	}
+
+	fmt.Fprintf(os.Stderr, "using prompt template %s\n\n", source)
+	fmt.Println(prompt)
 	return nil
 }
+
+// renderPromptFromCommand loads the prompt template for the target directory and renders
+// it using the discovered files, the resolved settings and the prompt flags of the provided
+// command. the location of the template is returned along with the rendered prompt.
+func renderPromptFromCommand[T any](cmd *cli.Command, target string, files map[string]T, settings Settings) (string, string, error) {
+	text, source, err := loadPromptTemplateFromCommand(cmd, target, settings)
+	if err != nil {
+		return "", source, err
+	}
+
+	data := newPromptData(target, relativePaths(target, files))
+	data.Outline = settings.Sections
+	for _, section := range settings.Sections {
+		data.Sections = append(data.Sections, section.Name)
+	}
+	data.Audience = cmd.String("audience")
+	data.Tone = cmd.String("tone")
+
+	prompt, err := renderPrompt(text, data)
+	return prompt, source, err
+}
+
+// loadPromptTemplateFromCommand loads the prompt template configured in the settings, or
+// the prompt template with the highest precedence for the target directory and the config
+// path of the provided command. the location of the template is returned along with its contents.
+func loadPromptTemplateFromCommand(cmd *cli.Command, target string, settings Settings) (string, string, error) {
+	if len(settings.PromptTemplate) > 0 {
+		source := resolvePath(target, settings.PromptTemplate)
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return "", source, err
+		}
+		return string(content), source, nil
+	}
+	return loadPromptTemplate(target, filepath.Dir(cmd.String("config-path")))
+}
+
+// ConfigShowCLICommand prints the global config, with the access token masked. With
+// --resolved, the effective settings for the target directory are printed instead, along
+// with the source each setting was resolved from.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config cannot be loaded or the settings cannot be resolved, otherwise nil.
+func ConfigShowCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	if !cmd.Bool("resolved") {
+		cfgPath := cmd.String("config-path")
+		config, err := loadConfigFromCommand(cmd)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+
+		file, _ := readConfigFile(cfgPath)
+		fmt.Printf("profile        %s\n", file.Profile(profileFromCommand(cmd)))
+		fmt.Printf("accessToken    %s\n", maskSecret(config.AccessToken))
+		fmt.Printf("modelVersion   %s\n", config.ModelVersion)
+		fmt.Printf("assistantId    %s\n", config.AssistantId)
+		fmt.Printf("vectorStoreId  %s\n", config.VectorStoreId)
+		return nil
+	}
+
+	target := cmd.String("target")
+	if !isValidDir(target) {
+		return cli.Exit(fmt.Sprintf("path %s either does not exist or is not a valid directory", target), 1)
+	}
+
+	settings, err := resolveSettings(cmd, target, os.LookupEnv)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("error resolving settings: %s", err), 1)
+	}
+
+	values := settings.Values()
+	for _, name := range settingNames {
+		fmt.Printf("%-16s %-30s (%s)\n", name, values[name], settings.Sources[name])
+	}
+	return nil
+}
+
+// ConfigListCLICommand prints the profiles of the config file. The default profile
+// is marked with an asterisk.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config file cannot be read, otherwise nil.
+func ConfigListCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	cfgPath := cmd.String("config-path")
+	file, err := readConfigFile(cfgPath)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	defaultProfile := file.Profile("")
+	for _, name := range file.ProfileNames() {
+		marker := " "
+		if name == defaultProfile {
+			marker = "*"
+		}
+		profile := file.Profiles[name]
+		fmt.Printf("%s %-16s %-20s %s\n", marker, name, profile.ModelVersion, maskSecret(profile.AccessToken))
+	}
+	return nil
+}
+
+// ConfigMigrateCLICommand upgrades the config file to the current schema version, keeping
+// a backup of the original next to the config file.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config file cannot be read or upgraded, otherwise nil.
+func ConfigMigrateCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	cfgPath := cmd.String("config-path")
+	// the config file is read first so that invalid files are reported like other commands
+	if _, err := readConfigFile(cfgPath); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	version, backup, err := migrateConfigFile(cfgPath)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error upgrading config file: %+v", err))
+		return cli.Exit(fmt.Sprintf("error upgrading config file %s: %s", cfgPath, err), 1)
+	}
+	if len(backup) == 0 {
+		fmt.Printf("config file %s already uses version %d\n", cfgPath, version)
+	}
+	return nil
+}
+
+// assistantClientFromCommand loads the config of the provided command and creates a
+// client using the access token of the config.
+func assistantClientFromCommand(cmd *cli.Command) (*ChatGPTAssistantClient, Config, error) {
+	config, err := loadClientConfigFromCommand(cmd)
+	if err != nil {
+		return nil, config, err
+	}
+
+	client := NewChatGPTAssistantClient(config.ModelVersion, ChatGPTCredentials{
+		Secret: config.AccessToken,
+	})
+	return client, config, nil
+}
+
+// assistantIdFromCommand returns the assistant ID passed as the first argument of the
+// provided command, or the assistant of the config if no ID is passed.
+func assistantIdFromCommand(cmd *cli.Command, config Config) string {
+	if id := cmd.Args().First(); len(id) > 0 {
+		return id
+	}
+	return config.AssistantId
+}
+
+// AssistantListCLICommand prints the assistants created by goreadme, or every assistant
+// of the account when using --all. The assistant of the config is marked with an asterisk.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config cannot be loaded or the assistants cannot be listed, otherwise nil.
+func AssistantListCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	assistants, err := listGoreadmeAssistants(client, cmd.Bool("all"))
+	if err != nil {
+		log.Debug(fmt.Sprintf("error listing assistants: %+v", err))
+		return cli.Exit(fmt.Sprintf("error listing assistants: %s", chatGPTErrorReason(err)), 1)
+	}
+
+	for _, assistant := range assistants {
+		marker := " "
+		if assistant.Id == config.AssistantId {
+			marker = "*"
+		}
+		created := time.Unix(assistant.CreatedAt, 0).Format(time.DateOnly)
+		fmt.Printf("%s %-32s %-16s %-16s %s\n", marker, assistant.Id, assistant.Name, assistant.Model, created)
+	}
+	return nil
+}
+
+// AssistantShowCLICommand prints the details of the assistant passed as an argument, or
+// the assistant of the config if no assistant is passed.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config cannot be loaded or the assistant cannot be retrieved, otherwise nil.
+func AssistantShowCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	id := assistantIdFromCommand(cmd, config)
+	assistant, err := client.GetAssistant(id)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error fetching assistant %s from chatgpt api: %+v", id, err))
+		return cli.Exit(fmt.Sprintf("error fetching assistant %s: %s", id, chatGPTErrorReason(err)), 1)
+	}
+
+	fmt.Print(formatAssistant(assistant))
+	return nil
+}
+
+// AssistantUpdateCLICommand changes the model, instructions or attached vector stores of
+// the assistant passed as an argument, or the assistant of the config if no assistant is
+// passed. Instructions are either passed directly, read from a file, or rendered from the
+// prompt template of the target directory using --sync-instructions.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if no changes are requested, or the assistant cannot be updated, otherwise nil.
+func AssistantUpdateCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	update := AssistantUpdate{}
+	if cmd.IsSet("model") {
+		update.Model = cmd.String("model")
+	}
+
+	sources := 0
+	for _, name := range []string{"instructions", "instructions-file", "sync-instructions"} {
+		if cmd.IsSet(name) {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return cli.Exit("only one of --instructions, --instructions-file and --sync-instructions can be used", 1)
+	}
+
+	switch {
+	case cmd.IsSet("instructions"):
+		instructions := cmd.String("instructions")
+		update.Instructions = &instructions
+	case cmd.IsSet("instructions-file"):
+		content, err := os.ReadFile(cmd.String("instructions-file"))
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error reading instructions file: %s", err), 1)
+		}
+		instructions := strings.TrimSpace(string(content))
+		update.Instructions = &instructions
+	case cmd.Bool("sync-instructions"):
+		target := cmd.String("target")
+		settings, err := resolveSettings(cmd, target, os.LookupEnv)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error resolving settings: %s", err), 1)
+		}
+
+		text, source, err := loadPromptTemplateFromCommand(cmd, target, settings)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error loading prompt template %s: %s", source, err), 1)
+		}
+		instructions, err := assistantInstructions(text)
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("error rendering prompt template %s: %s", source, err), 1)
+		}
+		update.Instructions = &instructions
+	}
+
+	if vectorStoreIds := cmd.StringSlice("vector-store"); len(vectorStoreIds) > 0 {
+		update.ToolResources = &AssistantToolResources{}
+		update.ToolResources.FileSearch.VectorStoreIds = vectorStoreIds
+	}
+
+	if len(update.Model) == 0 && update.Instructions == nil && update.ToolResources == nil {
+		return cli.Exit("nothing to update: use --model, --instructions, --instructions-file, --sync-instructions or --vector-store", 1)
+	}
+
+	id := assistantIdFromCommand(cmd, config)
+	assistant, err := client.UpdateAssistant(id, update)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error updating assistant %s: %+v", id, err))
+		return cli.Exit(fmt.Sprintf("error updating assistant %s: %s", id, chatGPTErrorReason(err)), 1)
+	}
+
+	fmt.Print(formatAssistant(assistant))
+	return nil
+}
+
+// AssistantDeleteCLICommand deletes the assistant passed as an argument. The assistant
+// must be passed explicitly, so that the assistant of the config is not deleted by accident.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if no assistant is passed or the assistant cannot be deleted, otherwise nil.
+func AssistantDeleteCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	id := cmd.Args().First()
+	if len(id) == 0 {
+		return cli.Exit("the ID of the assistant to delete is required", 1)
+	}
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	if err := client.DeleteAssistant(id); err != nil {
+		log.Debug(fmt.Sprintf("error deleting assistant %s: %+v", id, err))
+		return cli.Exit(fmt.Sprintf("error deleting assistant %s: %s", id, chatGPTErrorReason(err)), 1)
+	}
+
+	fmt.Printf("deleted assistant %s\n", id)
+	if id == config.AssistantId {
+		log.Warn(fmt.Sprintf("assistant %s is used by the config, run goreadme configure to create a new assistant", id))
+	}
+	return nil
+}
+
+// deleteRunVectorStore deletes the vector store created for a run of generate. Errors are
+// only logged, as the vector store expires on its own.
+func deleteRunVectorStore(client *ChatGPTAssistantClient, id string) {
+	if err := client.DeleteVectorStore(id); err != nil {
+		log.Debug(fmt.Sprintf("error deleting run vector store %s, it will expire after %d day(s): %+v", id, runVectorStoreExpiryDays, err))
+	}
+}
+
+// vectorStoreIdFromCommand returns the vector store ID passed as the first argument of the
+// provided command, or the vector store of the config if no ID is passed.
+func vectorStoreIdFromCommand(cmd *cli.Command, config Config) string {
+	if id := cmd.Args().First(); len(id) > 0 {
+		return id
+	}
+	return config.VectorStoreId
+}
+
+// VectorStoreListCLICommand prints the vector stores created by goreadme, or every vector
+// store of the account when using --all. The vector store of the config is marked with an asterisk.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config cannot be loaded or the vector stores cannot be listed, otherwise nil.
+func VectorStoreListCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	stores, err := listGoreadmeVectorStores(client, cmd.Bool("all"))
+	if err != nil {
+		log.Debug(fmt.Sprintf("error listing vector stores: %+v", err))
+		return cli.Exit(fmt.Sprintf("error listing vector stores: %s", chatGPTErrorReason(err)), 1)
+	}
+
+	for _, store := range stores {
+		marker := " "
+		if store.Id == config.VectorStoreId {
+			marker = "*"
+		}
+		created := time.Unix(store.CreatedAt, 0).Format(time.DateOnly)
+		fmt.Printf("%s %-32s %-16s %-12s %5d files  %s\n", marker, store.Id, store.Name, store.Status, store.FileCounts.Total, created)
+	}
+	return nil
+}
+
+// VectorStoreShowCLICommand prints the details of the vector store passed as an argument,
+// or the vector store of the config if no vector store is passed.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config cannot be loaded or the vector store cannot be retrieved, otherwise nil.
+func VectorStoreShowCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	id := vectorStoreIdFromCommand(cmd, config)
+	store, err := client.GetVectorStore(id)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error fetching vector store %s from chatgpt api: %+v", id, err))
+		return cli.Exit(fmt.Sprintf("error fetching vector store %s: %s", id, chatGPTErrorReason(err)), 1)
+	}
+
+	fmt.Print(formatVectorStore(store))
+	return nil
+}
+
+// VectorStoreFilesCLICommand prints the files attached to the vector store passed as an
+// argument, or the vector store of the config if no vector store is passed.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if the config cannot be loaded or the files cannot be listed, otherwise nil.
+func VectorStoreFilesCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	id := vectorStoreIdFromCommand(cmd, config)
+	files, err := client.ListVectorStoreFiles(id, ListOptions{}).All()
+	if err != nil {
+		log.Debug(fmt.Sprintf("error listing files of vector store %s: %+v", id, err))
+		return cli.Exit(fmt.Sprintf("error listing files of vector store %s: %s", id, chatGPTErrorReason(err)), 1)
+	}
+
+	for _, file := range files {
+		created := time.Unix(file.CreatedAt, 0).Format(time.DateOnly)
+		line := fmt.Sprintf("%-32s %-12s %10d bytes  %s", file.Id, file.Status, file.UsageBytes, created)
+		if file.LastError != nil {
+			line += fmt.Sprintf("  %s: %s", file.LastError.Code, file.LastError.Message)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// VectorStorePurgeCLICommand detaches every file from the vector store passed as an argument.
+// Like delete, the vector store must be passed explicitly, and the vector store of the config
+// is only purged if --yes is set. The detached files are kept, as they may be used by other
+// vector stores or assistants, unless --delete-files is set.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if no vector store is passed without --yes, the config cannot be loaded or
+//     any file cannot be purged, otherwise nil.
+func VectorStorePurgeCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	if cmd.Args().Len() == 0 && !cmd.Bool("yes") {
+		return cli.Exit("the ID of the vector store to purge is required, use --yes to purge the configured vector store", 1)
+	}
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	id := vectorStoreIdFromCommand(cmd, config)
+	purged, errors := purgeVectorStore(client, id, cmd.Bool("delete-files"))
+	for _, err := range errors {
+		log.Debug(fmt.Sprintf("error purging vector store %s: %+v", id, err))
+		fmt.Fprintf(os.Stderr, "%s\n", chatGPTErrorReason(err))
+	}
+
+	fmt.Printf("purged %d files from vector store %s\n", purged, id)
+	if len(errors) > 0 {
+		return cli.Exit(fmt.Sprintf("%d files could not be purged from vector store %s", len(errors), id), 1)
+	}
+	return nil
+}
+
+// VectorStoreDeleteCLICommand deletes the vector store passed as an argument. The vector store
+// must be passed explicitly, so that the vector store of the config is not deleted by accident.
+//
+// Parameters:
+//   - ctx: The context for the command execution.
+//   - cmd: The CLI command containing the arguments and flags.
+//
+// Returns:
+//   - An error if no vector store is passed or the vector store cannot be deleted, otherwise nil.
+func VectorStoreDeleteCLICommand(ctx context.Context, cmd *cli.Command) error {
+	// configure logging for application
+	configureLogging(cmd.String("log-level"))
+
+	id := cmd.Args().First()
+	if len(id) == 0 {
+		return cli.Exit("the ID of the vector store to delete is required", 1)
+	}
+
+	client, config, err := assistantClientFromCommand(cmd)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	if err := client.DeleteVectorStore(id); err != nil {
+		log.Debug(fmt.Sprintf("error deleting vector store %s: %+v", id, err))
+		return cli.Exit(fmt.Sprintf("error deleting vector store %s: %s", id, chatGPTErrorReason(err)), 1)
+	}
+
+	fmt.Printf("deleted vector store %s\n", id)
+	if id == config.VectorStoreId {
+		log.Warn(fmt.Sprintf("vector store %s is used by the config, run goreadme configure to create a new vector store", id))
+	}
+	return nil
+}
+
+// spinnerProgress creates a progress callback that shows the number of files transferred
+// in the prefix of the spinner, and logs each file that fails.
+func spinnerProgress(indicator *spinner.Spinner, verb string) TransferProgress {
+	return func(result TransferResult, done, total int) {
+		if result.Err != nil {
+			log.Debug(fmt.Sprintf("error transferring file %s: %+v", result.Name, result.Err))
+		}
+		indicator.Lock()
+		indicator.Prefix = fmt.Sprintf("%s %d of %d files (%s) ", verb, done, total, result.Name)
+		indicator.Unlock()
+	}
+}
@@ -6,99 +6,338 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 	log "github.com/sirupsen/logrus"
 )
 
-// loadConfig loads the configuration from the specified file path.
-// It returns a Config struct and an error if any issues are encountered.
+const DefaultProfileName = "default"
+
+// ConfigOverrides contains values that fill or override the fields of the config
+// file, e.g. from environment variables or CLI flags. empty values are ignored.
+type ConfigOverrides struct {
+	AccessToken   string
+	ModelVersion  string
+	AssistantId   string
+	VectorStoreId string
+}
+
+// configEnvVars maps each overridable config field to the environment variables it is
+// read from, in order of precedence.
+var configEnvVars = map[string][]string{
+	"accessToken":   {"GOREADME_ACCESS_TOKEN", "OPENAI_API_KEY"},
+	"modelVersion":  {"GOREADME_MODEL"},
+	"assistantId":   {"GOREADME_ASSISTANT_ID"},
+	"vectorStoreId": {"GOREADME_VECTOR_STORE_ID"},
+}
+
+// configOverridesFromEnv reads the config overrides from the environment variables
+// in configEnvVars using the provided lookup function, usually os.LookupEnv.
+func configOverridesFromEnv(lookupEnv func(key string) (string, bool)) ConfigOverrides {
+	lookup := func(field string) string {
+		for _, key := range configEnvVars[field] {
+			if value, ok := lookupEnv(key); ok && len(value) > 0 {
+				return value
+			}
+		}
+		return ""
+	}
+
+	return ConfigOverrides{
+		AccessToken:   lookup("accessToken"),
+		ModelVersion:  lookup("modelVersion"),
+		AssistantId:   lookup("assistantId"),
+		VectorStoreId: lookup("vectorStoreId"),
+	}
+}
+
+// Merge returns the overrides with any non empty values of other taking precedence.
+func (overrides ConfigOverrides) Merge(other ConfigOverrides) ConfigOverrides {
+	pick := func(value, override string) string {
+		if len(override) > 0 {
+			return override
+		}
+		return value
+	}
+
+	return ConfigOverrides{
+		AccessToken:   pick(overrides.AccessToken, other.AccessToken),
+		ModelVersion:  pick(overrides.ModelVersion, other.ModelVersion),
+		AssistantId:   pick(overrides.AssistantId, other.AssistantId),
+		VectorStoreId: pick(overrides.VectorStoreId, other.VectorStoreId),
+	}
+}
+
+// Apply fills or overrides the fields of the config using the non empty overrides.
+func (overrides ConfigOverrides) Apply(config *Config) {
+	merged := ConfigOverrides{
+		AccessToken:   config.AccessToken,
+		ModelVersion:  config.ModelVersion,
+		AssistantId:   config.AssistantId,
+		VectorStoreId: config.VectorStoreId,
+	}.Merge(overrides)
+
+	config.AccessToken = merged.AccessToken
+	config.ModelVersion = merged.ModelVersion
+	config.AssistantId = merged.AssistantId
+	config.VectorStoreId = merged.VectorStoreId
+}
+
+// ConfigFile is the contents of the config file, holding one config for each named
+// profile. Config files of older schema versions are upgraded in memory when they are
+// read, see migrateConfig.
+type ConfigFile struct {
+	Version        int               `json:"version"`
+	DefaultProfile string            `json:"defaultProfile"`
+	Profiles       map[string]Config `json:"profiles"`
+}
+
+// Profile returns the name of the profile that is used if no profile is selected.
+func (file ConfigFile) Profile(name string) string {
+	if len(name) > 0 {
+		return name
+	}
+	if len(file.DefaultProfile) > 0 {
+		return file.DefaultProfile
+	}
+	return DefaultProfileName
+}
+
+// ProfileNames returns the names of all profiles of the config file, sorted by name.
+func (file ConfigFile) ProfileNames() []string {
+	names := []string{}
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// readConfigFile reads and decodes the config file at the specified path. Files of older
+// schema versions are upgraded in memory, and are only written back to disk by
+// migrateConfigFile. The profiles of the file are not validated.
 //
 // Parameters:
 //   - path: The file path to the configuration file.
 //
 // Returns:
-//   - Config: The loaded configuration struct.
-//   - error: An error if the configuration file is not found, is a directory,
-//     cannot be read, is invalid JSON, or fails validation.
+//   - ConfigFile: The decoded config file.
+//   - error: An error if the file does not exist, is a directory, cannot be read or is invalid JSON.
 //
 // Possible errors:
 //   - ConfigFileNotFoundError: If the configuration file does not exist.
-//   - InvalidConfigFileError: If the path is a directory, the file cannot be read,
-//     the JSON is invalid, or the configuration fails validation.
-func loadConfig(path string) (Config, error) {
-	var config Config
+//   - InvalidConfigFileError: If the path is a directory, the file cannot be read or the JSON is invalid.
+func readConfigFile(path string) (ConfigFile, error) {
+	file := ConfigFile{
+		Profiles: map[string]Config{},
+	}
 
 	stat, err := os.Stat(path)
 	if err != nil {
 		log.Debug(fmt.Sprintf("cannot find config file at path %s: %+v", path, err))
-		return config, ConfigFileNotFoundError{
+		return file, ConfigFileNotFoundError{
 			Path: path,
 		}
 	} else if stat.IsDir() {
 		log.Debug(fmt.Sprintf("cannot load config %s: path is directory, expected file", path))
-		return config, InvalidConfigFileError{
+		return file, InvalidConfigFileError{
 			Path: path,
 		}
 	}
+	warnIfReadableByOthers(path, stat)
 
 	contents, err := os.ReadFile(path)
 	if err != nil {
 		log.Debug(fmt.Sprintf("error reading config file: %+v", err))
-		return config, InvalidConfigFileError{
+		return file, InvalidConfigFileError{
 			Path: path,
 		}
 	}
 
-	if err := json.Unmarshal(contents, &config); err != nil {
+	decoded, version, err := decodeConfigFile(contents)
+	if err != nil {
 		log.Debug(fmt.Sprintf("error decoding config file: %+v", err))
-		return config, InvalidConfigFileError{
-			Path: path,
+		return file, InvalidConfigFileError{
+			Path:   path,
+			Reason: err.Error(),
 		}
 	}
+	warnIfOutdated(path, version)
+	if decoded.Profiles == nil {
+		decoded.Profiles = map[string]Config{}
+	}
+	return decoded, nil
+}
+
+// loadConfig loads the config of a profile from the specified file path, and applies
+// the provided overrides before the config is validated. The config file is optional
+// if the overrides provide every field of the config. Access tokens that reference a
+// secret (e.g. env:OPENAI_API_KEY) are returned as is, and are only resolved by commands
+// that use the ChatGPT API, using resolveAccessToken.
+// It returns a Config struct and an error if any issues are encountered.
+//
+// Parameters:
+//   - path: The file path to the configuration file.
+//   - profile: The name of the profile to load. The default profile of the file is used if empty.
+//   - overrides: Values that fill or override the fields of the configuration file.
+//
+// Returns:
+//   - Config: The loaded configuration struct.
+//   - error: An error if the configuration file is not found and the overrides are
+//     incomplete, is a directory, cannot be read, is invalid JSON, does not contain
+//     the profile, or fails validation.
+//
+// Possible errors:
+//   - ConfigFileNotFoundError: If the configuration file does not exist, and the
+//     overrides do not provide a valid configuration.
+//   - ProfileNotFoundError: If the configuration file does not contain the profile.
+//   - InvalidConfigFileError: If the path is a directory, the file cannot be read,
+//     the JSON is invalid, or the configuration fails validation.
+func loadConfig(path, profile string, overrides ConfigOverrides) (Config, error) {
+	var config Config
+
+	found := true
+	file, err := readConfigFile(path)
+	if err != nil {
+		if _, ok := err.(ConfigFileNotFoundError); !ok {
+			return config, err
+		}
+		found = false
+	}
+
+	if found {
+		name := file.Profile(profile)
+		existing, ok := file.Profiles[name]
+		if !ok {
+			return config, ProfileNotFoundError{
+				Path:    path,
+				Profile: name,
+			}
+		}
+		config = existing
+	}
+
+	overrides.Apply(&config)
 
 	// validate contents of config file using validator package
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	if err := validate.Struct(config); err != nil {
-		for _, err := range err.(validator.ValidationErrors) {
-			log.Debug(fmt.Sprintf("config validation error: %+v", err))
+	if err := validateConfig(config); err != nil {
+		log.Debug(fmt.Sprintf("config validation error: %+v", err))
+		if !found {
+			return config, ConfigFileNotFoundError{
+				Path: path,
+			}
 		}
 		return config, InvalidConfigFileError{
-			Path: path,
+			Path:   path,
+			Reason: fmt.Sprintf("profile %s: %s", file.Profile(profile), err),
 		}
 	}
 	return config, nil
 }
 
-// writeConfig writes the given configuration to a specified file path in JSON format.
+// resolveAccessToken resolves the access token of the config if it references a secret,
+// e.g. env:OPENAI_API_KEY, using the sources of newSecretSources. credential helpers and
+// passphrase prompts are run every time, so commands resolve the token once, and only
+// if they use the ChatGPT API.
+//
+// Parameters:
+//   - config: The loaded config.
+//   - path: The path of the config file, used to find the secrets file.
+//
+// Returns:
+//   - Config: The config using the resolved access token.
+//   - error: A SecretResolutionError if the secret cannot be resolved.
+func resolveAccessToken(config Config, path string) (Config, error) {
+	token, err := resolveSecret(config.AccessToken, newSecretSources(path))
+	if err != nil {
+		log.Debug(fmt.Sprintf("error resolving access token: %+v", err))
+		return config, err
+	}
+	config.AccessToken = token
+	return config, nil
+}
+
+// writeConfig writes the given configuration to a profile of the config file at the
+// specified path in JSON format. The file can only be read by the owner. The other profiles of an existing config file are kept,
+// and the written profile becomes the default profile if the file does not have one.
 // It ensures that the directory path exists, creating any necessary directories.
 // If the directory path is invalid, it returns an error.
 //
 // Parameters:
 //   - config: The configuration struct to be written to the file.
 //   - path: The file path where the configuration should be written.
+//   - profile: The name of the profile. The default profile of the file is used if empty.
 //
 // Returns:
 //   - error: An error if the directory path is invalid, if there is an issue creating directories,
-//     if there is an error converting the struct to JSON, or if there is an error writing the file.
-func writeConfig(config Config, path string) error {
+//     if an existing config file cannot be read, if there is an error converting the struct
+//     to JSON, or if there is an error writing the file.
+func writeConfig(config Config, path, profile string) error {
 	// Ensure the directory path exists
 	dir := filepath.Dir(path)
 	if dir == "." || dir == "/" {
 		return errors.New("invalid file path or directory")
 	}
 	// create any directories that need to be created
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// config files of older versions are upgraded before they are changed, so a
+	// backup of the original is kept
+	if _, err := os.Stat(path); err == nil {
+		if _, _, err := migrateConfigFile(path); err != nil {
+			log.Debug(fmt.Sprintf("error upgrading config file %s: %+v", path, err))
+		}
+	}
+
+	file, err := readConfigFile(path)
+	if _, ok := err.(ConfigFileNotFoundError); err != nil && !ok {
 		return err
 	}
+
+	name := file.Profile(profile)
+	file.Version = ConfigVersion
+	file.Profiles[name] = config
+	if len(file.DefaultProfile) == 0 {
+		file.DefaultProfile = name
+	}
+
 	// Convert the struct to JSON (with pretty formatting)
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := json.MarshalIndent(file, "", "  ")
 	if err != nil {
 		return err
 	}
-	// Write the JSON to the file
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	// Write the JSON to the file, which may contain access tokens
+	return writePrivateFile(path, data)
+}
+
+// validateConfig validates the config using the validator package. The error names
+// each field that failed validation, using the field names of the config file, and
+// the validation tag that failed.
+func validateConfig(config Config) error {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return name
+	})
+
+	err := validate.Struct(config)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
 		return err
 	}
-	return nil
+
+	messages := []string{}
+	for _, fieldErr := range validationErrors {
+		messages = append(messages, fmt.Sprintf("field %s failed validation on tag %s", fieldErr.Field(), fieldErr.Tag()))
+	}
+	return errors.New(strings.Join(messages, ", "))
 }
@@ -1,54 +1,70 @@
 package main
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	log "github.com/sirupsen/logrus"
 )
 
 // TestLoadConfig tests the loadConfig function to ensure it correctly loads
-// configuration from a JSON file and populates the Config struct fields.
-// It checks for the following:
+// configuration from version 1 and version 2 JSON files and populates the Config
+// struct fields. It checks for the following:
 // - AccessToken should be "TestToken"
 // - ModelVersion should be "test-model"
 // - AssistantId should be "assistant_test-id"
 // - VectorStoreId should be "vectorstore_test-id"
 // If any of these conditions are not met, the test will fail.
 func TestLoadConfig(t *testing.T) {
-	config, err := loadConfig("tests/config.json")
-	if err != nil {
-		t.Fatal(err)
-	}
+	for _, path := range []string{"tests/config.json", "tests/config_v2.json"} {
+		config, err := loadConfig(path, "", ConfigOverrides{})
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	if config.AccessToken != "TestToken" {
-		t.Fatalf("expected access token %s, got %s", "TestToken", config.AccessToken)
-	}
+		if config.AccessToken != "TestToken" {
+			t.Fatalf("%s: expected access token %s, got %s", path, "TestToken", config.AccessToken)
+		}
 
-	if config.ModelVersion != "test-model" {
-		t.Fatalf("expected model version %s, got %s", "test-model", config.ModelVersion)
-	}
+		if config.ModelVersion != "test-model" {
+			t.Fatalf("%s: expected model version %s, got %s", path, "test-model", config.ModelVersion)
+		}
 
-	if config.AssistantId != "assistant_test-id" {
-		t.Fatalf("expected assistant id %s, got %s", "assistant_test-id", config.AssistantId)
-	}
+		if config.AssistantId != "assistant_test-id" {
+			t.Fatalf("%s: expected assistant id %s, got %s", path, "assistant_test-id", config.AssistantId)
+		}
 
-	if config.VectorStoreId != "vectorstore_test-id" {
-		t.Fatalf("expected vector store id  %s, got %s", "vectorstore_test-id", config.VectorStoreId)
+		if config.VectorStoreId != "vectorstore_test-id" {
+			t.Fatalf("%s: expected vector store id  %s, got %s", path, "vectorstore_test-id", config.VectorStoreId)
+		}
 	}
 }
 
-// TestLoadConfigPartial tests the loadConfig function with a partial configuration file.
-// It expects an error to be returned when loading a partial config file.
-// The test checks if the error is of type InvalidConfigFileError.
+// TestLoadConfigPartial tests the loadConfig function with partial version 1 and
+// version 2 configuration files. It expects an error to be returned when loading a
+// partial config file. The test checks if the error is of type InvalidConfigFileError.
 func TestLoadConfigPartial(t *testing.T) {
-	_, err := loadConfig("tests/partial_config.json")
-	if err == nil {
-		t.Fatal("expected error while loading partial config")
-	}
+	for _, path := range []string{"tests/partial_config.json", "tests/partial_config_v2.json"} {
+		_, err := loadConfig(path, "", ConfigOverrides{})
+		if err == nil {
+			t.Fatalf("%s: expected error while loading partial config", path)
+		}
 
-	var invalidConfigErr InvalidConfigFileError
-	if !errors.As(err, &invalidConfigErr) {
-		t.Fatalf("expected InvalidConfigFileError, got %+v", err)
+		var invalidConfigErr InvalidConfigFileError
+		if !errors.As(err, &invalidConfigErr) {
+			t.Fatalf("%s: expected InvalidConfigFileError, got %+v", path, err)
+		}
+
+		want := "field accessToken failed validation on tag required"
+		if !strings.Contains(invalidConfigErr.Reason, want) {
+			t.Errorf("%s got: %s, want: %s", path, invalidConfigErr.Reason, want)
+		}
 	}
 }
 
@@ -56,7 +72,7 @@ func TestLoadConfigPartial(t *testing.T) {
 // when attempting to load a configuration file that does not exist. It verifies that
 // the error returned is of type ConfigFileNotFoundError.
 func TestLoadConfigNotFound(t *testing.T) {
-	_, err := loadConfig("tests/not_found_config.json")
+	_, err := loadConfig("tests/not_found_config.json", "", ConfigOverrides{})
 	if err == nil {
 		t.Fatal("expected error while loading not found config")
 	}
@@ -72,18 +88,18 @@ func TestLoadConfigNotFound(t *testing.T) {
 // and verifying that the changes were correctly saved. It also ensures that
 // the temporary updated configuration file is deleted after the test.
 func TestWriteConfig(t *testing.T) {
-	config, err := loadConfig("tests/config.json")
+	config, err := loadConfig("tests/config.json", "", ConfigOverrides{})
 	if err != nil {
 		t.Fatalf("error loading config: %+v", err)
 	}
 
 	config.AccessToken = "test-token-updated"
 
-	if err := writeConfig(config, "tests/config_updated.json"); err != nil {
+	if err := writeConfig(config, "tests/config_updated.json", ""); err != nil {
 		t.Fatalf("error writing updated config: %+v", err)
 	}
 
-	updated, err := loadConfig("tests/config_updated.json")
+	updated, err := loadConfig("tests/config_updated.json", "", ConfigOverrides{})
 	if err != nil {
 		t.Fatalf("error loading updated config: %+v", err)
 	}
@@ -96,3 +112,207 @@ func TestWriteConfig(t *testing.T) {
 		t.Fatalf("error deleting updated config file: %+v", err)
 	}
 }
+
+// TestLoadConfigOverrides tests that overrides replace the values of the config file,
+// and that a config file is not required if the overrides provide every field.
+func TestLoadConfigOverrides(t *testing.T) {
+	config, err := loadConfig("tests/config.json", "", ConfigOverrides{ModelVersion: "override-model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.ModelVersion != "override-model" || config.AccessToken != "TestToken" {
+		t.Fatalf("expected overridden model with token from file, got %+v", config)
+	}
+
+	overrides := ConfigOverrides{
+		AccessToken:   "env-token",
+		ModelVersion:  "env-model",
+		AssistantId:   "env-assistant",
+		VectorStoreId: "env-vector-store",
+	}
+	config, err = loadConfig("tests/not_found_config.json", "", overrides)
+	if err != nil {
+		t.Fatalf("expected config from overrides without config file, got %+v", err)
+	}
+
+	if config.AssistantId != "env-assistant" || config.VectorStoreId != "env-vector-store" {
+		t.Fatalf("expected config from overrides, got %+v", config)
+	}
+
+	// partial overrides do not create a valid config without a config file
+	_, err = loadConfig("tests/not_found_config.json", "", ConfigOverrides{AccessToken: "env-token"})
+	var configNotFound ConfigFileNotFoundError
+	if !errors.As(err, &configNotFound) {
+		t.Fatalf("expected ConfigFileNotFoundError, got %+v", err)
+	}
+}
+
+// TestConfigOverridesFromEnv tests reading overrides from environment variables, and
+// that GOREADME_ACCESS_TOKEN takes precedence over OPENAI_API_KEY.
+func TestConfigOverridesFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OPENAI_API_KEY":        "openai-token",
+		"GOREADME_MODEL":        "env-model",
+		"GOREADME_ASSISTANT_ID": "",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	overrides := configOverridesFromEnv(lookupEnv)
+	want := ConfigOverrides{AccessToken: "openai-token", ModelVersion: "env-model"}
+	if overrides != want {
+		t.Fatalf("got: %+v, want: %+v", overrides, want)
+	}
+
+	env["GOREADME_ACCESS_TOKEN"] = "goreadme-token"
+	merged := configOverridesFromEnv(lookupEnv).Merge(ConfigOverrides{ModelVersion: "flag-model"})
+	want = ConfigOverrides{AccessToken: "goreadme-token", ModelVersion: "flag-model"}
+	if merged != want {
+		t.Fatalf("got: %+v, want: %+v", merged, want)
+	}
+}
+
+// TestLoadConfigProfiles tests loading the default and named profiles of a config
+// file, and that writing a profile keeps the other profiles of the file.
+func TestLoadConfigProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	// legacy config files are converted to the default profile when a profile is written
+	legacy := `{"accessToken": "TestToken", "modelVersion": "test-model", "vectorStoreId": "vectorstore_test-id", "assistantId": "assistant_test-id"}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	internal := Config{
+		AccessToken:   "internal-token",
+		ModelVersion:  "internal-model",
+		AssistantId:   "internal-assistant",
+		VectorStoreId: "internal-vector-store",
+	}
+	if err := writeConfig(internal, path, "internal"); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(file.ProfileNames(), []string{"default", "internal"}) || file.DefaultProfile != DefaultProfileName {
+		t.Fatalf("unexpected config file %+v", file)
+	}
+
+	config, err := loadConfig(path, "", ConfigOverrides{})
+	if err != nil || config.AccessToken != "TestToken" {
+		t.Fatalf("expected default profile, got %+v, %v", config, err)
+	}
+
+	config, err = loadConfig(path, "internal", ConfigOverrides{})
+	if err != nil || config != internal {
+		t.Fatalf("expected internal profile, got %+v, %v", config, err)
+	}
+
+	_, err = loadConfig(path, "missing", ConfigOverrides{})
+	var profileNotFound ProfileNotFoundError
+	if !errors.As(err, &profileNotFound) || profileNotFound.Profile != "missing" {
+		t.Fatalf("expected ProfileNotFoundError, got %+v", err)
+	}
+}
+
+// TestMigrateConfigFile tests that version 1 config files are upgraded in memory when they
+// are loaded without changing the file, that migrateConfigFile upgrades the file keeping a
+// backup of the original, and that config files of newer versions are rejected.
+func TestMigrateConfigFile(t *testing.T) {
+	legacy, err := os.ReadFile("tests/config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, legacy, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := loadConfig(path, "", ConfigOverrides{})
+	if err != nil || config.AccessToken != "TestToken" {
+		t.Fatalf("expected migrated config, got %+v, %v", config, err)
+	}
+	if contents, err := os.ReadFile(path); err != nil || string(contents) != string(legacy) {
+		t.Errorf("expected config file to be unchanged when loaded, got: %s %v", contents, err)
+	}
+	if _, err := os.Stat(path + ".v1.bak"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no backup when loading the config file, got: %v", err)
+	}
+
+	version, backup, err := migrateConfigFile(path)
+	if err != nil || version != 1 || backup != path+".v1.bak" {
+		t.Fatalf("got: %d %s %v, want: %d %s", version, backup, err, 1, path+".v1.bak")
+	}
+	contents, err := os.ReadFile(backup)
+	if err != nil {
+		t.Fatalf("expected backup of original config file: %+v", err)
+	}
+	if string(contents) != string(legacy) {
+		t.Errorf("got: %s, want: %s", contents, legacy)
+	}
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != ConfigVersion || file.DefaultProfile != DefaultProfileName {
+		t.Errorf("got: %d (%s), want: %d (%s)", file.Version, file.DefaultProfile, ConfigVersion, DefaultProfileName)
+	}
+
+	// config files of the current version are not changed
+	if version, backup, err := migrateConfigFile(path); err != nil || version != ConfigVersion || len(backup) > 0 {
+		t.Errorf("got: %d %s %v, want: %d", version, backup, err, ConfigVersion)
+	}
+
+	newer := fmt.Sprintf(`{"version": %d, "profiles": {}}`, ConfigVersion+1)
+	if err := os.WriteFile(path, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readConfigFile(path)
+	var invalidConfigErr InvalidConfigFileError
+	if !errors.As(err, &invalidConfigErr) || !strings.Contains(invalidConfigErr.Reason, "newer than the supported version") {
+		t.Fatalf("expected InvalidConfigFileError for newer version, got %+v", err)
+	}
+}
+
+// TestWarnIfOutdated tests that loading a config file of an older version warns that it
+// can be migrated, once per file, and that current config files are not warned about.
+func TestWarnIfOutdated(t *testing.T) {
+	dir := t.TempDir()
+	paths := map[string]string{"tests/config.json": "", "tests/config_v2.json": ""}
+	for fixture := range paths {
+		contents, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths[fixture] = filepath.Join(dir, filepath.Base(fixture))
+		if err := os.WriteFile(paths[fixture], contents, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	for i := 0; i < 3; i++ {
+		for _, path := range paths {
+			if _, err := readConfigFile(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if warnings := strings.Count(output.String(), "level=warning"); warnings != 1 {
+		t.Errorf("got: %d, want: %d", warnings, 1)
+	}
+	if want := fmt.Sprintf("config file %s uses version 1, run goreadme config migrate", paths["tests/config.json"]); !strings.Contains(output.String(), want) {
+		t.Errorf("got: %s, want: %s", output.String(), want)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultModel = "gpt-4o-mini"
+
+	assistantName        = "goreadme"
+	assistantDescription = "You are an assistant for auto-generating READMEs and associated documentation."
+	// assistantMetadataKey is the metadata key used to mark assistants created by goreadme
+	assistantMetadataKey = "created_by"
+)
+
+// ConfigureResult is the result of the configure command, printed when using --json.
+type ConfigureResult struct {
+	ConfigPath    string `json:"configPath"`
+	Profile       string `json:"profile"`
+	ModelVersion  string `json:"modelVersion"`
+	VectorStoreId string `json:"vectorStoreId"`
+	AssistantId   string `json:"assistantId"`
+	// Created contains the IDs of the resources created while configuring, keyed by resource type
+	Created map[string]string `json:"created"`
+}
+
+// verifyToken creates a client using the access token and verifies the token
+// using the ChatGPT API.
+func verifyToken(token string) (*ChatGPTAssistantClient, error) {
+	client := NewChatGPTAssistantClient("", ChatGPTCredentials{
+		Secret: token,
+	})
+
+	// verify provided credentials using client
+	if err := client.VerifyCredentials(); err != nil {
+		log.Debug(fmt.Sprintf("error validating chatgpt token: %+v", err))
+		return client, err
+	}
+	return client, nil
+}
+
+// verifyModel checks that the model exists using the ChatGPT API. The default
+// model is used if no model is provided.
+func verifyModel(client *ChatGPTAssistantClient, model string) (string, error) {
+	if len(model) == 0 {
+		model = DefaultModel
+	}
+
+	if _, err := client.GetModel(model); err != nil {
+		log.Debug(fmt.Sprintf("error validating chatgpt model: %+v", err))
+		return "", err
+	}
+	return model, nil
+}
+
+// resolveVectorStore validates the vector store with the provided ID. If no ID is
+// provided, a new vector store is created if create is true.
+//
+// Parameters:
+//   - client: The client used to make requests to the ChatGPT API.
+//   - id: The ID of the vector store, or an empty string.
+//   - create: Whether a vector store should be created if no ID is provided.
+//
+// Returns:
+//   - string: The ID of the vector store.
+//   - bool: true if the vector store was created.
+//   - error: An error if the vector store does not exist or cannot be created.
+func resolveVectorStore(client *ChatGPTAssistantClient, id string, create bool) (string, bool, error) {
+	if len(id) == 0 {
+		if !create {
+			return "", false, errors.New("vector store ID is required if missing resources are not created")
+		}
+
+		id, err := client.CreateVectorStore(assistantName)
+		if err != nil {
+			log.Debug(fmt.Sprintf("error creating chatgpt vector store: %+v", err))
+			if chatGPTError, ok := err.(ChatGPTError); ok {
+				log.Debug(fmt.Sprintf("error response: %+v", chatGPTError.Body))
+			}
+			return "", false, err
+		}
+		return id, true, nil
+	}
+
+	if _, err := client.GetVectorStore(id); err != nil {
+		log.Debug(fmt.Sprintf("error validating chatgpt vector store: %+v", err))
+		return "", false, err
+	}
+	return id, false, nil
+}
+
+// resolveAssistant validates the assistant with the provided ID, and checks that it
+// has vector stores attached. If no ID is provided, a new assistant using the model
+// and vector store is created if create is true.
+//
+// Parameters:
+//   - client: The client used to make requests to the ChatGPT API.
+//   - id: The ID of the assistant, or an empty string.
+//   - model: The model used for created assistants.
+//   - vectorStoreId: The vector store attached to created assistants.
+//   - create: Whether an assistant should be created if no ID is provided.
+//
+// Returns:
+//   - string: The ID of the assistant.
+//   - bool: true if the assistant was created.
+//   - error: An error if the assistant is invalid or cannot be created.
+func resolveAssistant(client *ChatGPTAssistantClient, id, model, vectorStoreId string, create bool) (string, bool, error) {
+	if len(id) == 0 {
+		if !create {
+			return "", false, errors.New("assistant ID is required if missing resources are not created")
+		}
+
+		id, err := client.CreateAssistant(assistantName, assistantDescription, model, vectorStoreId)
+		if err != nil {
+			log.Debug(fmt.Sprintf("error creating chatgpt assistant: %+v", err))
+			if chatGPTError, ok := err.(ChatGPTError); ok {
+				log.Debug(fmt.Sprintf("error response: %+v", chatGPTError.Body))
+			}
+			return "", false, err
+		}
+		return id, true, nil
+	}
+
+	assistant, err := client.GetAssistant(id)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error validating chatgpt assistant: %+v", err))
+		return "", false, err
+	}
+
+	if len(assistant.ToolResources.FileSearch.VectorStoreIds) == 0 {
+		log.Debug("vector store ids not found in assistant tool resources")
+		return "", false, fmt.Errorf("vector store ids not found in assistant tool resources")
+	}
+	return id, false, nil
+}
+
+// storeAccessToken stores the access token using the token store, and returns the value
+// written to the access token field of the config file. Plaintext tokens are written to
+// the config file as is, encrypted tokens are written to the secrets file with the name
+// of the profile, and references to environment variables or credential helpers
+// (e.g. env:OPENAI_API_KEY) are written to the config file instead of the token.
+//
+// Parameters:
+//   - token: The validated access token.
+//   - store: The token store, either plaintext, encrypted or a secret reference.
+//   - path: The path of the config file.
+//   - profile: The profile the token is configured for.
+//
+// Returns:
+//   - string: The value of the access token field of the config file.
+//   - error: An error if the token store is invalid or the token cannot be encrypted.
+func storeAccessToken(token, store, path, profile string) (string, error) {
+	switch {
+	case len(store) == 0 || store == TokenStorePlaintext:
+		return token, nil
+	case store == TokenStoreEncrypted:
+		file, _ := readConfigFile(path)
+		name := file.Profile(profile)
+		if err := newEncryptedSecretSource(path).Store(name, token); err != nil {
+			return "", err
+		}
+		return "secrets:" + name, nil
+	case isSecretReference(store):
+		return store, nil
+	default:
+		return "", fmt.Errorf("invalid token store %s: expected plaintext, encrypted, env:<variable> or helper:<command>", store)
+	}
+}
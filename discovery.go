@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	SkipReasonPermission      = "permission denied"
+	SkipReasonSymlink         = "symlink not followed"
+	SkipReasonBrokenSymlink   = "broken symlink"
+	SkipReasonSymlinkLoop     = "symlink loop"
+	SkipReasonOtherFilesystem = "on another filesystem"
+)
+
+// DiscoveryOptions control how the target directory is walked when discovering files.
+type DiscoveryOptions struct {
+	// FollowSymlinks follows symlinks to files and directories. symlinks are skipped if false
+	FollowSymlinks bool
+	// SameFilesystem skips directories and files on a different filesystem to the target directory
+	SameFilesystem bool
+}
+
+// SkippedPath is a path of the target directory that was not discovered, and the reason it was skipped.
+type SkippedPath struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+func (skipped SkippedPath) String() string {
+	return fmt.Sprintf("%s: %s", skipped.Path, skipped.Reason)
+}
+
+// skipReason returns the reason a path that could not be read is skipped.
+func skipReason(err error) string {
+	if errors.Is(err, fs.ErrPermission) {
+		return SkipReasonPermission
+	}
+	var pathError *fs.PathError
+	if errors.As(err, &pathError) {
+		return pathError.Err.Error()
+	}
+	return err.Error()
+}
+
+// fileWalker walks the target directory, collecting the files that can be uploaded and
+// the paths that were skipped.
+type fileWalker struct {
+	options DiscoveryOptions
+	// device is the device of the target directory, used when SameFilesystem is set
+	device  uint64
+	files   map[string]SourceFile
+	skipped []SkippedPath
+}
+
+// skip records a skipped path.
+func (walker *fileWalker) skip(path, reason string) {
+	log.Debug(fmt.Sprintf("skipping %s: %s", path, reason))
+	walker.skipped = append(walker.skipped, SkippedPath{
+		Path:   path,
+		Reason: reason,
+	})
+}
+
+// walkDir walks the entries of a directory. Entries that are read before an error
+// are still walked, so a single unreadable entry never hides the rest of the directory.
+//
+// Parameters:
+//   - dir: The path of the directory.
+//   - ancestors: The directories containing dir, including dir, used to detect symlink loops.
+func (walker *fileWalker) walkDir(dir string, ancestors []os.FileInfo) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		walker.skip(dir, skipReason(err))
+	}
+	walker.walkEntries(dir, entries, ancestors)
+}
+
+// walkEntries walks the entries of a directory that have been read.
+func (walker *fileWalker) walkEntries(dir string, entries []fs.DirEntry, ancestors []os.FileInfo) {
+	for _, entry := range entries {
+		walker.walkEntry(filepath.Join(dir, entry.Name()), entry, ancestors)
+	}
+}
+
+// walkEntry adds a file to the discovered files, or walks a directory.
+func (walker *fileWalker) walkEntry(path string, entry fs.DirEntry, ancestors []os.FileInfo) {
+	var info os.FileInfo
+	var err error
+	if entry.Type()&fs.ModeSymlink != 0 {
+		info, err = os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			walker.skip(path, SkipReasonBrokenSymlink)
+			return
+		case err != nil:
+			walker.skip(path, skipReason(err))
+			return
+		case !walker.options.FollowSymlinks:
+			// only symlinks that would otherwise be discovered are reported
+			if _, allowed := isAllowedFile(path); allowed || info.IsDir() {
+				walker.skip(path, SkipReasonSymlink)
+			}
+			return
+		}
+	} else if info, err = entry.Info(); err != nil {
+		walker.skip(path, skipReason(err))
+		return
+	}
+
+	if walker.options.SameFilesystem {
+		if device, ok := fileDevice(info); ok && device != walker.device {
+			walker.skip(path, SkipReasonOtherFilesystem)
+			return
+		}
+	}
+
+	if info.IsDir() {
+		loop := slices.ContainsFunc(ancestors, func(ancestor os.FileInfo) bool {
+			return os.SameFile(ancestor, info)
+		})
+		if loop {
+			walker.skip(path, SkipReasonSymlinkLoop)
+			return
+		}
+		walker.walkDir(path, append(slices.Clip(ancestors), info))
+		return
+	}
+
+	if !info.Mode().IsRegular() {
+		return
+	}
+	// if entry is not in the allowed file types, skip
+	mappedFilename, allowed := isAllowedFile(path)
+	if !allowed {
+		return
+	}
+	log.Debug(fmt.Sprintf("adding file %s", path))
+	walker.files[mappedFilename] = newSourceFile(path, info.Size())
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+// newDiscoveryTarget creates a target directory containing a source file, a directory
+// outside of the target linked into it, a link to a source file, a broken link and a
+// link back to the target itself.
+func newDiscoveryTarget(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated permissions on windows")
+	}
+
+	root := t.TempDir()
+	target := filepath.Join(root, "target")
+	shared := filepath.Join(root, "shared")
+	for _, dir := range []string{target, shared} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := map[string]string{
+		filepath.Join(target, "main.py"): "print('main')",
+		filepath.Join(shared, "lib.py"):  "print('lib')",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		filepath.Join(target, "shared"):  shared,
+		filepath.Join(target, "link.py"): filepath.Join(target, "main.py"),
+		filepath.Join(target, "gone.py"): filepath.Join(root, "missing.py"),
+		filepath.Join(target, "loop"):    target,
+	}
+	for link, destination := range links {
+		if err := os.Symlink(destination, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return target
+}
+
+// TestGetFilesToUploadSymlinks tests that symlinks are skipped unless they are followed,
+// and that broken links and links back to a parent directory are reported as skipped.
+func TestGetFilesToUploadSymlinks(t *testing.T) {
+	target := newDiscoveryTarget(t)
+
+	tests := []struct {
+		name    string
+		options DiscoveryOptions
+		files   []string
+		skipped []SkippedPath
+	}{
+		{
+			name:  "not followed",
+			files: []string{"main.py"},
+			skipped: []SkippedPath{
+				{Path: "gone.py", Reason: SkipReasonBrokenSymlink},
+				{Path: "link.py", Reason: SkipReasonSymlink},
+				{Path: "loop", Reason: SkipReasonSymlink},
+				{Path: "shared", Reason: SkipReasonSymlink},
+			},
+		},
+		{
+			name:    "followed",
+			options: DiscoveryOptions{FollowSymlinks: true},
+			files:   []string{"link.py", "main.py", "shared/lib.py"},
+			skipped: []SkippedPath{
+				{Path: "gone.py", Reason: SkipReasonBrokenSymlink},
+				{Path: "loop", Reason: SkipReasonSymlinkLoop},
+			},
+		},
+		{
+			name:    "same filesystem",
+			options: DiscoveryOptions{FollowSymlinks: true, SameFilesystem: true},
+			files:   []string{"link.py", "main.py", "shared/lib.py"},
+			skipped: []SkippedPath{
+				{Path: "gone.py", Reason: SkipReasonBrokenSymlink},
+				{Path: "loop", Reason: SkipReasonSymlinkLoop},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, skipped, err := getFilesToUpload(target, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := relativePaths(target, files); !slices.Equal(got, test.files) {
+				t.Errorf("got: %v, want: %v", got, test.files)
+			}
+
+			for i := range skipped {
+				skipped[i].Path, _ = filepath.Rel(target, skipped[i].Path)
+			}
+			if !slices.Equal(skipped, test.skipped) {
+				t.Errorf("got: %v, want: %v", skipped, test.skipped)
+			}
+		})
+	}
+}
+
+// TestGetFilesToUploadPermissionDenied tests that unreadable directories are reported as
+// skipped rather than stopping the walk, while an unreadable target directory is an error.
+func TestGetFilesToUploadPermissionDenied(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for this user")
+	}
+
+	target := t.TempDir()
+	private := filepath.Join(target, "private")
+	if err := os.Mkdir(private, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "main.py"), []byte("print('main')"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(private, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(private, 0755)
+
+	files, skipped, err := getFilesToUpload(target, DiscoveryOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("got: %d, want: %d", len(files), 1)
+	}
+
+	expected := []SkippedPath{{Path: private, Reason: SkipReasonPermission}}
+	if !slices.Equal(skipped, expected) {
+		t.Errorf("got: %v, want: %v", skipped, expected)
+	}
+
+	if _, _, err := getFilesToUpload(private, DiscoveryOptions{}); err == nil {
+		t.Error("expected error when the target directory cannot be read")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// fileDevice returns the ID of the device containing the file. Devices are not
+// available on windows, so files are never skipped for being on another filesystem.
+func fileDevice(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DiagnosticStatus is the outcome of a single diagnostic check.
+type DiagnosticStatus string
+
+const (
+	DiagnosticPass DiagnosticStatus = "pass"
+	DiagnosticWarn DiagnosticStatus = "warn"
+	DiagnosticFail DiagnosticStatus = "fail"
+	// DiagnosticSkip is used for checks that cannot run because a check they depend on failed
+	DiagnosticSkip DiagnosticStatus = "skip"
+)
+
+// Diagnostic is the result of a single check of the doctor report.
+type Diagnostic struct {
+	Name    string           `json:"name"`
+	Status  DiagnosticStatus `json:"status"`
+	Message string           `json:"message"`
+}
+
+// DoctorReport contains the results of the diagnostic checks of a profile.
+type DoctorReport struct {
+	Profile     string       `json:"profile"`
+	ConfigPath  string       `json:"configPath"`
+	Passed      bool         `json:"passed"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// add appends a diagnostic to the report.
+func (report *DoctorReport) add(name string, status DiagnosticStatus, message string) {
+	report.Diagnostics = append(report.Diagnostics, Diagnostic{
+		Name:    name,
+		Status:  status,
+		Message: message,
+	})
+}
+
+// skip appends a skipped diagnostic for each of the named checks.
+func (report *DoctorReport) skip(reason string, names ...string) {
+	for _, name := range names {
+		report.add(name, DiagnosticSkip, reason)
+	}
+}
+
+// finish marks the report as passed if none of the checks failed.
+func (report DoctorReport) finish() DoctorReport {
+	report.Passed = !slices.ContainsFunc(report.Diagnostics, func(diagnostic Diagnostic) bool {
+		return diagnostic.Status == DiagnosticFail
+	})
+	return report
+}
+
+// String formats the report as a list of checks, one per line.
+func (report DoctorReport) String() string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("profile %s:\n", report.Profile))
+	for _, diagnostic := range report.Diagnostics {
+		builder.WriteString(fmt.Sprintf("  [%s] %s: %s\n", diagnostic.Status, diagnostic.Name, diagnostic.Message))
+	}
+	return builder.String()
+}
+
+// chatGPTErrorReason returns the reason for a failed ChatGPT API request, using the
+// error message of the response body if there is one.
+func chatGPTErrorReason(err error) string {
+	var chatGPTError ChatGPTError
+	if !errors.As(err, &chatGPTError) {
+		return err.Error()
+	}
+
+	if body, ok := chatGPTError.Body["error"].(map[string]interface{}); ok {
+		if message, ok := body["message"].(string); ok && len(message) > 0 {
+			return fmt.Sprintf("%s (status code %d)", message, chatGPTError.Code)
+		}
+	}
+	return chatGPTError.Error()
+}
+
+// checkConfigFile checks the location and permissions of the config file. A missing
+// config file is not a failure, as the config can be provided using overrides.
+//
+// Parameters:
+//   - path: The path of the config file.
+//
+// Returns:
+//   - DiagnosticStatus: The status of the check.
+//   - string: The reason for the status.
+func checkConfigFile(path string) (DiagnosticStatus, string) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DiagnosticWarn, fmt.Sprintf("%s not found, using environment variables and flags only", path)
+	}
+	if err != nil {
+		return DiagnosticFail, fmt.Sprintf("error reading %s: %s", path, err)
+	}
+	if info.IsDir() {
+		return DiagnosticFail, fmt.Sprintf("%s is a directory", path)
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0044 != 0 {
+		return DiagnosticWarn, fmt.Sprintf("%s has permissions %04o and can be read by other users, restrict them using chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+	return DiagnosticPass, fmt.Sprintf("%s (permissions %04o)", path, info.Mode().Perm())
+}
+
+// checkAssistantSetup checks that the assistant is attached to the configured vector
+// store, uses the file_search tool and uses the configured model.
+//
+// Parameters:
+//   - assistant: The assistant retrieved from the ChatGPT API.
+//   - config: The config of the profile.
+//
+// Returns:
+//   - []Diagnostic: The results of the attachment, tool and model checks.
+func checkAssistantSetup(assistant Assistant, config Config) []Diagnostic {
+	var report DoctorReport
+
+	vectorStoreIds := assistant.ToolResources.FileSearch.VectorStoreIds
+	if slices.Contains(vectorStoreIds, config.VectorStoreId) {
+		report.add("assistant vector store", DiagnosticPass, fmt.Sprintf("assistant is attached to %s", config.VectorStoreId))
+	} else {
+		report.add("assistant vector store", DiagnosticFail, fmt.Sprintf("assistant is attached to %v, not the configured vector store %s", vectorStoreIds, config.VectorStoreId))
+	}
+
+	usesFileSearch := slices.ContainsFunc(assistant.Tools, func(tool Tool) bool {
+		return tool.Type == "file_search"
+	})
+	if usesFileSearch {
+		report.add("assistant tools", DiagnosticPass, "assistant uses the file_search tool")
+	} else {
+		report.add("assistant tools", DiagnosticFail, "assistant does not use the file_search tool")
+	}
+
+	if assistant.Model == config.ModelVersion {
+		report.add("assistant model", DiagnosticPass, fmt.Sprintf("assistant uses %s", assistant.Model))
+	} else {
+		report.add("assistant model", DiagnosticFail, fmt.Sprintf("assistant uses %s, but the configured model is %s", assistant.Model, config.ModelVersion))
+	}
+	return report.Diagnostics
+}
+
+// runDiagnostics checks the config file and the config of a profile, reporting the
+// result of every check rather than stopping at the first failure. Checks that depend
+// on a failed check, e.g. every API check when the token is invalid, are skipped.
+//
+// Parameters:
+//   - path: The path of the config file.
+//   - profile: The profile to check, or an empty string for the default profile.
+//   - overrides: The overrides of the config file values.
+//
+// Returns:
+//   - DoctorReport: The results of the checks.
+func runDiagnostics(path, profile string, overrides ConfigOverrides) DoctorReport {
+	report := DoctorReport{
+		Profile:    profile,
+		ConfigPath: path,
+	}
+	if file, err := readConfigFile(path); err == nil {
+		report.Profile = file.Profile(profile)
+	} else if len(report.Profile) == 0 {
+		report.Profile = DefaultProfileName
+	}
+
+	status, message := checkConfigFile(path)
+	report.add("config file", status, message)
+
+	config, err := loadConfig(path, profile, overrides)
+	if err == nil {
+		config, err = resolveAccessToken(config, path)
+	}
+	if err != nil {
+		log.Debug(fmt.Sprintf("error loading profile %s: %+v", profile, err))
+		report.add("config", DiagnosticFail, err.Error())
+		report.skip("config could not be loaded", "token", "model", "vector store", "assistant", "upload round-trip")
+		return report.finish()
+	}
+	report.add("config", DiagnosticPass, "config is valid")
+
+	client := NewChatGPTAssistantClient(config.ModelVersion, ChatGPTCredentials{
+		Secret: config.AccessToken,
+	})
+
+	if err := client.VerifyCredentials(); err != nil {
+		log.Debug(fmt.Sprintf("error verifying chatgpt credentials: %+v", err))
+		report.add("token", DiagnosticFail, chatGPTErrorReason(err))
+		report.skip("token is invalid", "model", "vector store", "assistant", "upload round-trip")
+		return report.finish()
+	}
+	report.add("token", DiagnosticPass, fmt.Sprintf("token %s is valid", maskSecret(config.AccessToken)))
+
+	if _, err := client.GetModel(config.ModelVersion); err != nil {
+		log.Debug(fmt.Sprintf("error fetching model %s from chatgpt api: %+v", config.ModelVersion, err))
+		report.add("model", DiagnosticFail, fmt.Sprintf("model %s: %s", config.ModelVersion, chatGPTErrorReason(err)))
+	} else {
+		report.add("model", DiagnosticPass, fmt.Sprintf("model %s exists", config.ModelVersion))
+	}
+
+	vectorStore, err := client.GetVectorStore(config.VectorStoreId)
+	switch {
+	case err != nil:
+		log.Debug(fmt.Sprintf("error fetching vector store %s from chatgpt api: %+v", config.VectorStoreId, err))
+		report.add("vector store", DiagnosticFail, fmt.Sprintf("vector store %s: %s", config.VectorStoreId, chatGPTErrorReason(err)))
+	case vectorStore.Status == "expired":
+		report.add("vector store", DiagnosticFail, fmt.Sprintf("vector store %s has expired", config.VectorStoreId))
+	default:
+		report.add("vector store", DiagnosticPass, fmt.Sprintf("vector store %s exists (status %s)", config.VectorStoreId, vectorStore.Status))
+	}
+
+	assistant, err := client.GetAssistant(config.AssistantId)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error fetching assistant %s from chatgpt api: %+v", config.AssistantId, err))
+		report.add("assistant", DiagnosticFail, fmt.Sprintf("assistant %s: %s", config.AssistantId, chatGPTErrorReason(err)))
+		report.skip("assistant could not be retrieved", "assistant vector store", "assistant tools", "assistant model")
+	} else {
+		report.add("assistant", DiagnosticPass, fmt.Sprintf("assistant %s exists", config.AssistantId))
+		report.Diagnostics = append(report.Diagnostics, checkAssistantSetup(assistant, config)...)
+	}
+
+	// upload and delete a small file to check that files can be uploaded for generation
+	id, err := client.UploadFile("goreadme-doctor.txt", bytes.NewBufferString("goreadme upload check"))
+	if err != nil {
+		log.Debug(fmt.Sprintf("error uploading test file: %+v", err))
+		report.add("upload round-trip", DiagnosticFail, fmt.Sprintf("error uploading file: %s", chatGPTErrorReason(err)))
+		return report.finish()
+	}
+	if err := client.DeleteFile(id); err != nil {
+		log.Debug(fmt.Sprintf("error deleting test file %s: %+v", id, err))
+		report.add("upload round-trip", DiagnosticFail, fmt.Sprintf("uploaded file %s could not be deleted: %s", id, chatGPTErrorReason(err)))
+		return report.finish()
+	}
+	report.add("upload round-trip", DiagnosticPass, "file uploaded and deleted")
+
+	return report.finish()
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestCheckConfigFile tests that the config file check reports missing config files
+// and config files readable by other users as warnings, and directories as failures.
+func TestCheckConfigFile(t *testing.T) {
+	dir := t.TempDir()
+
+	private := filepath.Join(dir, "private.json")
+	if err := os.WriteFile(private, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	public := filepath.Join(dir, "public.json")
+	if err := os.WriteFile(public, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(public, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want DiagnosticStatus
+	}{
+		{path: private, want: DiagnosticPass},
+		{path: public, want: DiagnosticWarn},
+		{path: filepath.Join(dir, "missing.json"), want: DiagnosticWarn},
+		{path: dir, want: DiagnosticFail},
+	}
+
+	for _, test := range tests {
+		if status, message := checkConfigFile(test.path); status != test.want {
+			t.Errorf("%s: got: %s (%s), want: %s", test.path, status, message, test.want)
+		}
+	}
+}
+
+// TestCheckAssistantSetup tests that the assistant checks fail when the assistant is not
+// attached to the configured vector store, does not use file_search or uses another model.
+func TestCheckAssistantSetup(t *testing.T) {
+	config := Config{ModelVersion: "test-model", VectorStoreId: "vectorstore_test-id"}
+
+	var assistant Assistant
+	assistant.Model = "test-model"
+	assistant.Tools = []Tool{{Type: "file_search"}}
+	assistant.ToolResources.FileSearch.VectorStoreIds = []string{"vectorstore_test-id"}
+
+	for _, diagnostic := range checkAssistantSetup(assistant, config) {
+		if diagnostic.Status != DiagnosticPass {
+			t.Errorf("%s: got: %s (%s), want: %s", diagnostic.Name, diagnostic.Status, diagnostic.Message, DiagnosticPass)
+		}
+	}
+
+	assistant.Model = "other-model"
+	assistant.Tools = []Tool{{Type: "code_interpreter"}}
+	assistant.ToolResources.FileSearch.VectorStoreIds = []string{"vectorstore_other-id"}
+
+	failed := []string{}
+	for _, diagnostic := range checkAssistantSetup(assistant, config) {
+		if diagnostic.Status == DiagnosticFail {
+			failed = append(failed, diagnostic.Name)
+		}
+	}
+
+	want := []string{"assistant vector store", "assistant tools", "assistant model"}
+	if !slices.Equal(failed, want) {
+		t.Errorf("got: %v, want: %v", failed, want)
+	}
+}
+
+// TestRunDiagnosticsInvalidConfig tests that the API checks are skipped, and the report
+// fails, when the config of the profile cannot be loaded.
+func TestRunDiagnosticsInvalidConfig(t *testing.T) {
+	report := runDiagnostics("tests/partial_config.json", "", ConfigOverrides{})
+	if report.Passed {
+		t.Fatal("expected report to fail for invalid config")
+	}
+
+	statuses := map[string]DiagnosticStatus{}
+	for _, diagnostic := range report.Diagnostics {
+		statuses[diagnostic.Name] = diagnostic.Status
+	}
+
+	if statuses["config"] != DiagnosticFail || statuses["token"] != DiagnosticSkip || statuses["upload round-trip"] != DiagnosticSkip {
+		t.Errorf("got: %v, want: config failed and api checks skipped", statuses)
+	}
+}
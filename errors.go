@@ -4,9 +4,14 @@ import "fmt"
 
 type InvalidConfigFileError struct {
 	Path string
+	// Reason explains why the config file is invalid, e.g. the field that failed validation
+	Reason string
 }
 
 func (e InvalidConfigFileError) Error() string {
+	if len(e.Reason) > 0 {
+		return fmt.Sprintf("error loading config file at provided path %s: %s", e.Path, e.Reason)
+	}
 	return fmt.Sprintf("error loading config file at provided path %s", e.Path)
 }
 
@@ -18,6 +23,36 @@ func (e ConfigFileNotFoundError) Error() string {
 	return fmt.Sprintf("cannot find config file at provided path %s", e.Path)
 }
 
+type ProfileNotFoundError struct {
+	Path    string
+	Profile string
+}
+
+func (e ProfileNotFoundError) Error() string {
+	return fmt.Sprintf("cannot find profile %s in config file %s", e.Profile, e.Path)
+}
+
+type SecretResolutionError struct {
+	Reference string
+	Err       error
+}
+
+func (e SecretResolutionError) Error() string {
+	return fmt.Sprintf("error resolving secret %s: %s", e.Reference, e.Err)
+}
+
+func (e SecretResolutionError) Unwrap() error {
+	return e.Err
+}
+
+type SecretsFoundError struct {
+	Findings []SecretFinding
+}
+
+func (e SecretsFoundError) Error() string {
+	return fmt.Sprintf("found %d secrets in the files to upload", len(e.Findings))
+}
+
 type ChatGPTErrorType string
 
 const (
@@ -34,3 +69,30 @@ type ChatGPTError struct {
 func (e ChatGPTError) Error() string {
 	return fmt.Sprintf("received ChatGPT error type %s: status code %d", e.Type, e.Code)
 }
+
+type NoAssistantOutputError struct {
+	ThreadId string
+	RunId    string
+}
+
+func (e NoAssistantOutputError) Error() string {
+	return fmt.Sprintf("no assistant message found for run %s in thread %s", e.RunId, e.ThreadId)
+}
+
+type EmptyAssistantOutputError struct {
+	MessageId string
+}
+
+func (e EmptyAssistantOutputError) Error() string {
+	return fmt.Sprintf("assistant message %s does not contain any text", e.MessageId)
+}
+
+type ReplayMismatchError struct {
+	Path   string
+	Method string
+	URL    string
+}
+
+func (e ReplayMismatchError) Error() string {
+	return fmt.Sprintf("no recorded response for %s %s in fixture %s", e.Method, e.URL, e.Path)
+}
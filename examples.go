@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	packageClauseRegex = regexp.MustCompile(`(?m)^\s*package\s+\w+`)
+	importLineRegex    = regexp.MustCompile(`^\s*import\s+(\w+\s+)?"[^"]+"\s*$`)
+	buildOutputRegex   = regexp.MustCompile(`^(?:\./)?example(\d+)/example\.go:(\d+):(?:\d+:)?\s*(.*)$`)
+	unusedRegex        = regexp.MustCompile(`declared and not used|imported and not used|is not used$`)
+)
+
+// CodeBlock is a fenced code block found in a markdown document.
+type CodeBlock struct {
+	// Info is the info string following the opening fence, e.g. go
+	Info string
+	// Line is the line number of the first line of code in the block
+	Line int
+	Code string
+}
+
+// extractCodeBlocks returns all fenced code blocks in the markdown content.
+// blocks that are not closed before the end of the document are ignored.
+func extractCodeBlocks(content string) []CodeBlock {
+	blocks := []CodeBlock{}
+
+	var fence fenceState
+	var current *CodeBlock
+	lines := []string{}
+	for i, line := range strings.Split(content, "\n") {
+		if !fence.Update(line) {
+			if current != nil {
+				lines = append(lines, line)
+			}
+			continue
+		}
+
+		if fence.Open() {
+			_, info, _ := parseFence(line)
+			current = &CodeBlock{Info: info, Line: i + 2}
+			lines = []string{}
+		} else if current != nil {
+			current.Code = strings.Join(lines, "\n")
+			blocks = append(blocks, *current)
+			current = nil
+		}
+	}
+	return blocks
+}
+
+// GoExample is a Go code block from a README, wrapped into a compilable source file.
+type GoExample struct {
+	Block  CodeBlock
+	Source string
+	// Offset is the number of lines added before the code of the block
+	Offset int
+	// Fragment is true if the code was wrapped into a function body
+	Fragment bool
+}
+
+// wrapGoExample wraps the code of a Go code block into a source file that can be compiled.
+// Blocks with a package clause are used as is, blocks containing declarations are
+// added to a package, and any other code is treated as a list of statements and
+// wrapped in a function. import lines found in statement blocks are moved to the top
+// of the file. Imports for packages used in the code but not imported are added
+// using the provided map of package names to import paths.
+func wrapGoExample(block CodeBlock, packages map[string]string) GoExample {
+	example := GoExample{Block: block}
+
+	if packageClauseRegex.MatchString(block.Code) {
+		example.Source = block.Code
+		return example
+	}
+
+	header := "package example"
+	declarations := header + "\n" + block.Code
+	if _, err := parser.ParseFile(token.NewFileSet(), "example.go", declarations, parser.SkipObjectResolution); err == nil {
+		example.Source = declarations
+		example.Offset = 1
+	} else {
+		imports := []string{}
+		body := []string{}
+		for _, line := range strings.Split(block.Code, "\n") {
+			if importLineRegex.MatchString(line) {
+				imports = append(imports, strings.TrimSpace(line))
+				line = ""
+			}
+			body = append(body, line)
+		}
+
+		for _, statement := range imports {
+			header += "; " + statement
+		}
+		example.Source = header + "\nfunc _() {\n" + strings.Join(body, "\n") + "\n}\n"
+		example.Offset = 2
+		example.Fragment = true
+	}
+
+	if missing := missingImports(example.Source, packages); len(missing) > 0 {
+		quoted := []string{}
+		for _, importPath := range missing {
+			quoted = append(quoted, strconv.Quote(importPath))
+		}
+		// imports are added to the first line so the line numbers of the code do not change
+		lines := strings.SplitN(example.Source, "\n", 2)
+		lines[0] += "; import (" + strings.Join(quoted, "; ") + ")"
+		example.Source = strings.Join(lines, "\n")
+	}
+	return example
+}
+
+// missingImports returns the import paths of packages that are referenced in the
+// source using a selector (e.g. fmt.Println) but are neither imported nor declared.
+func missingImports(source string, packages map[string]string) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "example.go", source, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	declared := map[string]bool{}
+	for _, spec := range file.Imports {
+		name := path.Base(strings.Trim(spec.Path.Value, `"`))
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		declared[name] = true
+	}
+
+	missing := []string{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			for _, expr := range node.Lhs {
+				if ident, ok := expr.(*ast.Ident); ok {
+					declared[ident.Name] = true
+				}
+			}
+		case *ast.ValueSpec:
+			for _, name := range node.Names {
+				declared[name.Name] = true
+			}
+		case *ast.Field:
+			for _, name := range node.Names {
+				declared[name.Name] = true
+			}
+		case *ast.SelectorExpr:
+			ident, ok := node.X.(*ast.Ident)
+			if !ok || declared[ident.Name] {
+				return true
+			}
+			if importPath, ok := packages[ident.Name]; ok && !slices.Contains(missing, importPath) {
+				missing = append(missing, importPath)
+			}
+		}
+		return true
+	})
+	return missing
+}
+
+// readModulePath returns the module path declared in the go.mod file of a directory.
+func readModulePath(dir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), nil
+		}
+	}
+	return "", errors.New("module directive not found in go.mod")
+}
+
+// goCommand creates a go command that runs offline in the given directory.
+func goCommand(dir string, args ...string) *exec.Cmd {
+	command := exec.Command("go", args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	return command
+}
+
+// listPackages returns a map of package names to import paths for the packages
+// in the standard library and in the module at the target directory.
+func listPackages(target string) (map[string]string, error) {
+	packages := map[string]string{}
+
+	for _, pattern := range []string{"std", "./..."} {
+		output, err := goCommand(target, "list", "-e", "-f", "{{.Name}} {{.ImportPath}}", pattern).Output()
+		if err != nil {
+			return packages, err
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			name, importPath, ok := strings.Cut(scanner.Text(), " ")
+			if !ok || name == "main" || strings.Contains(importPath, "internal") || strings.Contains(importPath, "vendor/") {
+				continue
+			}
+			// prefer the shortest import path if package names clash
+			if existing, ok := packages[name]; !ok || len(importPath) < len(existing) {
+				packages[name] = importPath
+			}
+		}
+	}
+	return packages, nil
+}
+
+// compileGoExamples compiles the Go code blocks of a README against the module in the
+// target directory, and returns the compiler diagnostics as problems. Each block is
+// wrapped using wrapGoExample and written to a separate package of a scratch module that
+// replaces the target module with the local directory. The go command runs offline, so
+// all dependencies of the target module must be present in the module cache. Unused
+// variables and imports are not reported for code fragments.
+//
+// Parameters:
+//   - target: The target directory containing the go.mod of the module.
+//   - content: The README content.
+//
+// Returns:
+//   - []Problem: The compiler diagnostics, with line numbers of the README.
+//   - error: An error if the scratch module cannot be created or the go command cannot be run.
+func compileGoExamples(target, content string) ([]Problem, error) {
+	problems := []Problem{}
+
+	blocks := []CodeBlock{}
+	for _, block := range extractCodeBlocks(content) {
+		if info := strings.ToLower(block.Info); info == "go" || info == "golang" {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return problems, nil
+	}
+
+	modulePath, err := readModulePath(target)
+	if err != nil {
+		return problems, err
+	}
+
+	absolute, err := filepath.Abs(target)
+	if err != nil {
+		return problems, err
+	}
+
+	packages, err := listPackages(target)
+	if err != nil {
+		return problems, fmt.Errorf("error listing go packages: %w", err)
+	}
+
+	scratch, err := os.MkdirTemp("", "goreadme-examples-")
+	if err != nil {
+		return problems, err
+	}
+	defer os.RemoveAll(scratch)
+
+	goMod := fmt.Sprintf("module goreadme.examples\n\nrequire %s v0.0.0\n\nreplace %s => %s\n", modulePath, modulePath, absolute)
+	if err := os.WriteFile(filepath.Join(scratch, "go.mod"), []byte(goMod), 0644); err != nil {
+		return problems, err
+	}
+	if sum, err := os.ReadFile(filepath.Join(target, "go.sum")); err == nil {
+		if err := os.WriteFile(filepath.Join(scratch, "go.sum"), sum, 0644); err != nil {
+			return problems, err
+		}
+	}
+
+	examples := []GoExample{}
+	for i, block := range blocks {
+		example := wrapGoExample(block, packages)
+		examples = append(examples, example)
+
+		dir := filepath.Join(scratch, fmt.Sprintf("example%d", i))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return problems, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, "example.go"), []byte(example.Source), 0644); err != nil {
+			return problems, err
+		}
+	}
+
+	output, err := goCommand(scratch, "build", "-gcflags=-e", "./...").CombinedOutput()
+	if err == nil {
+		return problems, nil
+	} else if _, ok := err.(*exec.ExitError); !ok {
+		return problems, err
+	}
+	log.Debug(fmt.Sprintf("go build output for README examples:\n%s", output))
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		match := buildOutputRegex.FindStringSubmatch(line)
+		if match == nil {
+			// errors that are not related to a specific example, e.g. module resolution errors
+			if !strings.HasPrefix(line, "#") && len(strings.TrimSpace(line)) > 0 && !strings.HasPrefix(line, "\t") {
+				problems = append(problems, Problem{Check: "examples", Message: line})
+			}
+			continue
+		}
+
+		index, _ := strconv.Atoi(match[1])
+		number, _ := strconv.Atoi(match[2])
+		example := examples[index]
+		if example.Fragment && unusedRegex.MatchString(match[3]) {
+			continue
+		}
+
+		// diagnostics on added lines are reported on the first line of the block
+		readmeLine := example.Block.Line
+		if number > example.Offset {
+			readmeLine += min(number-example.Offset-1, strings.Count(example.Block.Code, "\n"))
+		}
+		problems = append(problems, Problem{
+			Check:   "examples",
+			Line:    readmeLine,
+			Message: fmt.Sprintf("go example does not compile: %s", match[3]),
+		})
+	}
+	return problems, nil
+}
+
+// newExamplesCheck creates a check that compiles the Go code examples of a README.
+func newExamplesCheck(target string) ReadmeCheck {
+	return ReadmeCheck{
+		Name:     "examples",
+		Guidance: "Go code examples must compile against the attached source code, using only exported identifiers of the module.",
+		Run: func(content string) ([]Problem, error) {
+			return compileGoExamples(target, content)
+		},
+	}
+}
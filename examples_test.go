@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestExtractCodeBlocks tests that extractCodeBlocks returns the info string,
+// first line number and code of every closed fenced code block.
+func TestExtractCodeBlocks(t *testing.T) {
+	content := "# Title\n\n```go\nfmt.Println(1)\n```\n\n~~~\nplain\n~~~\n\n```bash\nunclosed"
+
+	blocks := extractCodeBlocks(content)
+	if len(blocks) != 2 {
+		t.Fatalf("got: %d, want: %d", len(blocks), 2)
+	}
+
+	if blocks[0].Info != "go" || blocks[0].Line != 4 || blocks[0].Code != "fmt.Println(1)" {
+		t.Errorf("got: %+v, want: go block on line 4", blocks[0])
+	}
+
+	if blocks[1].Info != "" || blocks[1].Line != 8 || blocks[1].Code != "plain" {
+		t.Errorf("got: %+v, want: plain block on line 8", blocks[1])
+	}
+}
+
+// TestWrapGoExample tests that wrapGoExample wraps package files, declarations and
+// statement fragments, and adds imports for packages that are used but not imported.
+func TestWrapGoExample(t *testing.T) {
+	packages := map[string]string{
+		"fmt":     "fmt",
+		"strings": "strings",
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		want     string
+		offset   int
+		fragment bool
+	}{
+		{
+			name:   "package file",
+			code:   "package main\n\nfunc main() {}",
+			want:   "package main\n\nfunc main() {}",
+			offset: 0,
+		},
+		{
+			name:   "declarations",
+			code:   "func hello() string {\n\treturn strings.ToUpper(\"hello\")\n}",
+			want:   "package example; import (\"strings\")\nfunc hello() string {\n\treturn strings.ToUpper(\"hello\")\n}",
+			offset: 1,
+		},
+		{
+			name:     "statements",
+			code:     "import \"os\"\n\nfmt.Println(os.Args)",
+			want:     "package example; import \"os\"; import (\"fmt\")\nfunc _() {\n\n\nfmt.Println(os.Args)\n}\n",
+			offset:   2,
+			fragment: true,
+		},
+		{
+			name:     "local variables are not imported",
+			code:     "strings := []string{}\nfmt.Println(len(strings))",
+			want:     "package example; import (\"fmt\")\nfunc _() {\nstrings := []string{}\nfmt.Println(len(strings))\n}\n",
+			offset:   2,
+			fragment: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			example := wrapGoExample(CodeBlock{Info: "go", Line: 1, Code: test.code}, packages)
+			if example.Source != test.want {
+				t.Errorf("got: %q, want: %q", example.Source, test.want)
+			}
+			if example.Offset != test.offset || example.Fragment != test.fragment {
+				t.Errorf("got: %d %v, want: %d %v", example.Offset, example.Fragment, test.offset, test.fragment)
+			}
+		})
+	}
+}
+
+// TestCompileGoExamples tests that compileGoExamples compiles Go examples against a
+// scratch module, and reports compiler errors on the matching README lines.
+func TestCompileGoExamples(t *testing.T) {
+	target := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module example.com/sample\n\ngo 1.22\n",
+		"sample.go": "package sample\n\n// Hello returns a greeting.\nfunc Hello(name string) string {\n\treturn \"hello \" + name\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(target, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content := "# Sample\n\n```go\ngreeting := sample.Hello(\"world\")\nfmt.Println(greeting)\n```\n\n" +
+		"```go\nunused := 1\nsample.Goodbye()\n```\n\n```bash\nsample.Missing()\n```\n"
+
+	problems, err := compileGoExamples(target, content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, problem := range problems {
+		got = append(got, problem.String())
+	}
+
+	if len(got) != 1 || !strings.HasPrefix(got[0], "[examples] line 10:") || !strings.Contains(got[0], "Goodbye") {
+		t.Errorf("got: %v, want: undefined Goodbye on line 10", got)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	inlineLinkRegex    = regexp.MustCompile(`(!?)\[[^\]]*\]\(\s*(?:<([^>]+)>|([^)\s]+))(?:\s+(?:"[^"]*"|'[^']*'|\([^)]*\)))?\s*\)`)
+	referenceLinkRegex = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	htmlLinkRegex      = regexp.MustCompile(`(?i)<(img|a|source)\b[^>]*?\s(?:src|href)\s*=\s*["']([^"']+)["']`)
+	autoLinkRegex      = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+)
+
+// Link is a link or image reference found in a markdown document.
+type Link struct {
+	Target string
+	Line   int
+	Image  bool
+}
+
+// IsExternal checks if the link points to a URL rather than a file or anchor.
+func (link Link) IsExternal() bool {
+	return strings.Contains(link.Target, "://") || strings.HasPrefix(link.Target, "mailto:")
+}
+
+// URLChecker checks that an external URL can be reached.
+type URLChecker interface {
+	Check(ctx context.Context, url string) error
+}
+
+// HTTPURLChecker checks external URLs using HTTP requests. A HEAD request is sent
+// first, falling back to a GET request for servers that do not support HEAD.
+// results are cached so every URL is only requested once.
+type HTTPURLChecker struct {
+	Client  *http.Client
+	results map[string]error
+}
+
+// NewHTTPURLChecker creates a new HTTPURLChecker using the given request timeout.
+func NewHTTPURLChecker(timeout time.Duration) *HTTPURLChecker {
+	return &HTTPURLChecker{
+		Client: &http.Client{
+			Timeout: timeout,
+		},
+		results: map[string]error{},
+	}
+}
+
+func (checker *HTTPURLChecker) Check(ctx context.Context, target string) error {
+	if err, ok := checker.results[target]; ok {
+		return err
+	}
+
+	err := checker.request(ctx, http.MethodHead, target)
+	if err != nil {
+		err = checker.request(ctx, http.MethodGet, target)
+	}
+	checker.results[target] = err
+	return err
+}
+
+func (checker *HTTPURLChecker) request(ctx context.Context, method, target string) error {
+	request, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := checker.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("received status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+// extractLinks returns the inline links and images, reference link definitions, HTML
+// links and images, and autolinks of a markdown document. links inside code blocks and
+// inline code spans are ignored.
+func extractLinks(content string) []Link {
+	links := []Link{}
+
+	var fence fenceState
+	for i, line := range strings.Split(content, "\n") {
+		if fence.Update(line) || fence.Open() {
+			continue
+		}
+
+		number := i + 1
+		line = inlineCodeRegex.ReplaceAllString(line, "")
+
+		for _, match := range inlineLinkRegex.FindAllStringSubmatch(line, -1) {
+			target := match[2]
+			if len(target) == 0 {
+				target = match[3]
+			}
+			links = append(links, Link{Target: target, Line: number, Image: match[1] == "!"})
+		}
+		if match := referenceLinkRegex.FindStringSubmatch(line); match != nil {
+			links = append(links, Link{Target: match[1], Line: number})
+		}
+		for _, match := range htmlLinkRegex.FindAllStringSubmatch(line, -1) {
+			links = append(links, Link{Target: match[2], Line: number, Image: !strings.EqualFold(match[1], "a")})
+		}
+		for _, match := range autoLinkRegex.FindAllStringSubmatch(line, -1) {
+			links = append(links, Link{Target: match[1], Line: number})
+		}
+	}
+	return links
+}
+
+// findBrokenLinks checks the relative links and images of a markdown document against
+// the files in the root directory, and anchors against the headings of the document.
+// anchors of links to other markdown files are checked against the headings of the
+// linked file. External links are not checked.
+//
+// Parameters:
+//   - content: The markdown content.
+//   - root: The directory containing the markdown document.
+//
+// Returns:
+//   - []Link: The broken links.
+//   - []string: The reason each of the links is broken.
+func findBrokenLinks(content, root string) ([]Link, []string) {
+	broken := []Link{}
+	reasons := []string{}
+
+	anchors := headingAnchors(content)
+	for _, link := range extractLinks(content) {
+		if link.IsExternal() {
+			continue
+		}
+
+		target, anchor, _ := strings.Cut(link.Target, "#")
+		target, _, _ = strings.Cut(target, "?")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+
+		if len(target) == 0 {
+			if len(anchor) > 0 && !anchors[strings.ToLower(anchor)] {
+				broken = append(broken, link)
+				reasons = append(reasons, fmt.Sprintf("anchor #%s does not match any heading", anchor))
+			}
+			continue
+		}
+
+		kind := "relative link"
+		if link.Image {
+			kind = "image"
+		}
+
+		path := filepath.Join(root, filepath.FromSlash(target))
+		if _, err := os.Stat(path); err != nil {
+			broken = append(broken, link)
+			reasons = append(reasons, fmt.Sprintf("%s %s does not exist", kind, target))
+			continue
+		}
+
+		if len(anchor) > 0 && strings.EqualFold(filepath.Ext(target), ".md") {
+			linked, err := os.ReadFile(path)
+			if err == nil && !headingAnchors(string(linked))[strings.ToLower(anchor)] {
+				broken = append(broken, link)
+				reasons = append(reasons, fmt.Sprintf("anchor #%s does not match any heading in %s", anchor, target))
+			}
+		}
+	}
+	return broken, reasons
+}
+
+// newURLCheck creates a check that reports external links that cannot be reached
+// using the provided checker.
+func newURLCheck(checker URLChecker) ReadmeCheck {
+	return ReadmeCheck{
+		Name:     "urls",
+		Guidance: "Links to external URLs must point to pages that exist.",
+		Run: func(content string) ([]Problem, error) {
+			problems := []Problem{}
+			for _, link := range extractLinks(content) {
+				if !link.IsExternal() || strings.HasPrefix(link.Target, "mailto:") {
+					continue
+				}
+				if err := checker.Check(context.Background(), link.Target); err != nil {
+					problems = append(problems, Problem{
+						Check:   "urls",
+						Line:    link.Line,
+						Message: fmt.Sprintf("external link %s cannot be reached: %s", link.Target, err),
+					})
+				}
+			}
+			return problems, nil
+		},
+	}
+}
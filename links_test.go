@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// fakeURLChecker is a URLChecker that fails for a fixed set of URLs.
+type fakeURLChecker struct {
+	broken []string
+}
+
+func (checker fakeURLChecker) Check(ctx context.Context, url string) error {
+	if slices.Contains(checker.broken, url) {
+		return errors.New("not found")
+	}
+	return nil
+}
+
+// TestExtractLinks tests that extractLinks finds inline links and images, reference
+// definitions, HTML links and autolinks, ignoring code blocks and inline code.
+func TestExtractLinks(t *testing.T) {
+	content := "# Title\n\n" +
+		"[docs](docs/index.md \"Docs\") ![logo](<img/logo one.png>) `[code](ignored.md)`\n" +
+		"[ref]: ./LICENSE\n" +
+		"<img alt=\"x\" src=\"img/banner.svg\"> <a href=\"#title\">top</a>\n" +
+		"<https://example.com>\n\n" +
+		"```markdown\n[block](ignored.md)\n```\n"
+
+	got := extractLinks(content)
+
+	want := []Link{
+		{Target: "docs/index.md", Line: 3},
+		{Target: "img/logo one.png", Line: 3, Image: true},
+		{Target: "./LICENSE", Line: 4},
+		{Target: "img/banner.svg", Line: 5, Image: true},
+		{Target: "#title", Line: 5},
+		{Target: "https://example.com", Line: 6},
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
+// TestFindBrokenLinks tests that findBrokenLinks reports missing files, images and
+// anchors, including anchors of links to other markdown files.
+func TestFindBrokenLinks(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "usage.md"), []byte("# Usage\n\n## Flags\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "# Title\n\n## Install\n\n" +
+		"[install](#install) [missing](#missing)\n" +
+		"[usage](docs/usage.md#flags) [bad anchor](docs/usage.md#nope) [dir](docs/)\n" +
+		"![logo](img/logo.png) [ext](https://example.com/missing)\n"
+
+	_, reasons := findBrokenLinks(content, root)
+	want := []string{
+		"anchor #missing does not match any heading",
+		"anchor #nope does not match any heading in docs/usage.md",
+		"image img/logo.png does not exist",
+	}
+
+	if !slices.Equal(reasons, want) {
+		t.Errorf("got: %v, want: %v", reasons, want)
+	}
+}
+
+// TestURLCheck tests that the URL check reports external links rejected by the checker.
+func TestURLCheck(t *testing.T) {
+	check := newURLCheck(fakeURLChecker{broken: []string{"https://example.com/missing"}})
+
+	problems, err := check.Run("# Title\n\n[ok](https://example.com)\n[missing](https://example.com/missing)\n[local](README.md)\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) != 1 || problems[0].Line != 4 {
+		t.Errorf("got: %v, want: a single problem on line 4", problems)
+	}
+}
+
+// TestHTTPURLChecker tests that the HTTPURLChecker falls back to GET requests
+// and reports error status codes.
+func TestHTTPURLChecker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/no-head" && r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	checker := NewHTTPURLChecker(5 * time.Second)
+	ctx := context.Background()
+
+	if err := checker.Check(ctx, server.URL+"/ok"); err != nil {
+		t.Errorf("expected no error, got %+v", err)
+	}
+
+	if err := checker.Check(ctx, server.URL+"/no-head"); err != nil {
+		t.Errorf("expected no error, got %+v", err)
+	}
+
+	if err := checker.Check(ctx, server.URL+"/missing"); err == nil {
+		t.Error("expected error for missing page")
+	}
+}
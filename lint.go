@@ -0,0 +1,336 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+var (
+	slugRegex    = regexp.MustCompile(`[^\p{L}\p{N}\s_-]`)
+	urlOnlyRegex = regexp.MustCompile(`^\s*(?:[-*+]\s+|\d+[.)]\s+)?<?\[?[^\s]*https?://\S+\s*$`)
+)
+
+// LintConfig configures the rules used to lint markdown content.
+type LintConfig struct {
+	// Root is the directory relative links are resolved against
+	Root string
+	// Disabled contains the names of rules that should not be run
+	Disabled []string
+	// MaxLineLength is the maximum length of prose lines. 0 disables the limit
+	MaxLineLength int
+}
+
+// Heading is an ATX heading found in a markdown document.
+type Heading struct {
+	Level int
+	Text  string
+	Line  int
+}
+
+// LintRule is a single markdown lint rule. Fix is nil for rules that cannot be fixed automatically.
+type LintRule struct {
+	Name        string
+	Description string
+	Check       func(content string, config LintConfig) []Problem
+	Fix         func(content string, config LintConfig) string
+}
+
+// lintRules are all of the rules supported by the markdown linter, in the order they are run.
+var lintRules = []LintRule{
+	{
+		Name:        "single-h1",
+		Description: "the document must contain exactly one level one heading",
+		Check:       checkSingleH1,
+		Fix:         fixSingleH1,
+	},
+	{
+		Name:        "heading-increment",
+		Description: "heading levels must only increase by one level at a time",
+		Check:       checkHeadingIncrement,
+		Fix:         fixHeadingIncrement,
+	},
+	{
+		Name:        "fenced-code-language",
+		Description: "fenced code blocks must have a language tag",
+		Check:       checkFencedCodeLanguage,
+		Fix:         fixFencedCodeLanguage,
+	},
+	{
+		Name:        "no-broken-links",
+		Description: "relative links and images must point to existing files and anchors to existing headings",
+		Check:       checkBrokenLinks,
+	},
+	{
+		Name:        "line-length",
+		Description: "prose lines must not exceed the maximum line length",
+		Check:       checkLineLength,
+		Fix:         fixLineLength,
+	},
+}
+
+// enabledLintRules returns the rules that are not disabled by the config.
+func enabledLintRules(config LintConfig) []LintRule {
+	rules := []LintRule{}
+	for _, rule := range lintRules {
+		if !slices.Contains(config.Disabled, rule.Name) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// lintMarkdown runs all of the enabled lint rules against the markdown content.
+func lintMarkdown(content string, config LintConfig) []Problem {
+	problems := []Problem{}
+	for _, rule := range enabledLintRules(config) {
+		problems = append(problems, rule.Check(content, config)...)
+	}
+	return problems
+}
+
+// fixMarkdown applies the fixes of all enabled lint rules that support automatic fixes.
+func fixMarkdown(content string, config LintConfig) string {
+	for _, rule := range enabledLintRules(config) {
+		if rule.Fix != nil {
+			content = rule.Fix(content, config)
+		}
+	}
+	return content
+}
+
+// newLintCheck creates a check that lints the README using the provided config.
+func newLintCheck(config LintConfig) ReadmeCheck {
+	return ReadmeCheck{
+		Name:     "lint",
+		Guidance: "The README must have a single level one heading, must not skip heading levels, must tag fenced code blocks with a language and must not contain broken relative links.",
+		Run: func(content string) ([]Problem, error) {
+			return lintMarkdown(content, config), nil
+		},
+	}
+}
+
+// lintProblem creates a problem reported by the lint rule with the given name.
+func lintProblem(rule string, line int, format string, args ...any) Problem {
+	return Problem{
+		Check:   "lint",
+		Line:    line,
+		Message: fmt.Sprintf("%s: %s", rule, fmt.Sprintf(format, args...)),
+	}
+}
+
+// parseHeadings returns all ATX headings of the document that are not part of code blocks.
+func parseHeadings(content string) []Heading {
+	headings := []Heading{}
+
+	var fence fenceState
+	for i, line := range strings.Split(content, "\n") {
+		if fence.Update(line) || fence.Open() {
+			continue
+		}
+		if match := headingRegex.FindStringSubmatch(line); match != nil {
+			text := strings.TrimSpace(strings.TrimRight(match[2], "# "))
+			headings = append(headings, Heading{Level: len(match[1]), Text: text, Line: i + 1})
+		}
+	}
+	return headings
+}
+
+// rewriteHeadings calls fn for every heading of the document, replacing the
+// heading level with the level returned by fn.
+func rewriteHeadings(content string, fn func(heading Heading) int) string {
+	return forEachProseLine(content, func(line string) (string, bool) {
+		match := headingRegex.FindStringSubmatch(line)
+		if match == nil {
+			return line, true
+		}
+		level := fn(Heading{Level: len(match[1]), Text: match[2]})
+		return strings.Repeat("#", level) + " " + match[2], true
+	})
+}
+
+func checkSingleH1(content string, config LintConfig) []Problem {
+	problems := []Problem{}
+
+	count := 0
+	for _, heading := range parseHeadings(content) {
+		if heading.Level != 1 {
+			continue
+		}
+		count++
+		if count > 1 {
+			problems = append(problems, lintProblem("single-h1", heading.Line, "multiple level one headings, found %q", heading.Text))
+		}
+	}
+
+	if count == 0 {
+		problems = append(problems, lintProblem("single-h1", 0, "document does not contain a level one heading"))
+	}
+	return problems
+}
+
+// fixSingleH1 demotes every level one heading after the first to a level two heading.
+func fixSingleH1(content string, config LintConfig) string {
+	found := false
+	return rewriteHeadings(content, func(heading Heading) int {
+		if heading.Level == 1 {
+			if found {
+				return 2
+			}
+			found = true
+		}
+		return heading.Level
+	})
+}
+
+func checkHeadingIncrement(content string, config LintConfig) []Problem {
+	problems := []Problem{}
+
+	previous := 0
+	for _, heading := range parseHeadings(content) {
+		if previous > 0 && heading.Level > previous+1 {
+			problems = append(problems, lintProblem("heading-increment", heading.Line, "heading level %d follows level %d", heading.Level, previous))
+		}
+		previous = heading.Level
+	}
+	return problems
+}
+
+// fixHeadingIncrement lowers the level of headings that skip levels to one
+// level below the previous heading.
+func fixHeadingIncrement(content string, config LintConfig) string {
+	previous := 0
+	return rewriteHeadings(content, func(heading Heading) int {
+		level := heading.Level
+		if previous > 0 && level > previous+1 {
+			level = previous + 1
+		}
+		previous = level
+		return level
+	})
+}
+
+func checkFencedCodeLanguage(content string, config LintConfig) []Problem {
+	problems := []Problem{}
+	for _, block := range extractCodeBlocks(content) {
+		if len(block.Info) == 0 {
+			problems = append(problems, lintProblem("fenced-code-language", block.Line-1, "code block does not have a language tag"))
+		}
+	}
+	return problems
+}
+
+// fixFencedCodeLanguage tags fenced code blocks without a language as text.
+func fixFencedCodeLanguage(content string, config LintConfig) string {
+	lines := strings.Split(content, "\n")
+
+	var fence fenceState
+	for i, line := range lines {
+		if fence.Update(line) && fence.Open() {
+			if _, info, _ := parseFence(line); len(info) == 0 {
+				lines[i] = strings.TrimRight(line, " \t") + "text"
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headingSlug converts heading text into the anchor generated by GitHub.
+func headingSlug(text string) string {
+	text = strings.ToLower(strings.TrimSpace(text))
+	text = slugRegex.ReplaceAllString(text, "")
+	return strings.ReplaceAll(text, " ", "-")
+}
+
+// headingAnchors returns the anchors of all headings in the document. duplicate
+// headings receive a numbered suffix, matching the behaviour of GitHub.
+func headingAnchors(content string) map[string]bool {
+	anchors := map[string]bool{}
+	counts := map[string]int{}
+	for _, heading := range parseHeadings(content) {
+		slug := headingSlug(heading.Text)
+		if count := counts[slug]; count > 0 {
+			anchors[fmt.Sprintf("%s-%d", slug, count)] = true
+		} else {
+			anchors[slug] = true
+		}
+		counts[slug]++
+	}
+	return anchors
+}
+
+func checkBrokenLinks(content string, config LintConfig) []Problem {
+	problems := []Problem{}
+
+	broken, reasons := findBrokenLinks(content, config.Root)
+	for i, link := range broken {
+		problems = append(problems, lintProblem("no-broken-links", link.Line, "%s", reasons[i]))
+	}
+	return problems
+}
+
+// isProseLine checks if a line outside of code blocks contains wrappable prose.
+// headings, tables, html, block quotes and lines only containing a URL are excluded.
+func isProseLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) == 0 || headingRegex.MatchString(trimmed) || urlOnlyRegex.MatchString(trimmed) {
+		return false
+	}
+	return !strings.HasPrefix(trimmed, "|") && !strings.HasPrefix(trimmed, "<") && !strings.HasPrefix(trimmed, ">")
+}
+
+func checkLineLength(content string, config LintConfig) []Problem {
+	problems := []Problem{}
+	if config.MaxLineLength <= 0 {
+		return problems
+	}
+
+	var fence fenceState
+	for i, line := range strings.Split(content, "\n") {
+		if fence.Update(line) || fence.Open() || !isProseLine(line) {
+			continue
+		}
+		if length := len([]rune(line)); length > config.MaxLineLength {
+			problems = append(problems, lintProblem("line-length", i+1, "line length %d exceeds maximum of %d", length, config.MaxLineLength))
+		}
+	}
+	return problems
+}
+
+// fixLineLength wraps prose lines that are longer than the maximum line length at word
+// boundaries. continuation lines of list items are indented to the list item content.
+func fixLineLength(content string, config LintConfig) string {
+	if config.MaxLineLength <= 0 {
+		return content
+	}
+
+	return forEachProseLine(content, func(line string) (string, bool) {
+		if len([]rune(line)) <= config.MaxLineLength || !isProseLine(line) {
+			return line, true
+		}
+
+		indent := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+		continuation := strings.Repeat(" ", indent)
+		if trimmed := line[indent:]; isListItem(trimmed) {
+			marker, _, _ := strings.Cut(trimmed, " ")
+			continuation = strings.Repeat(" ", indent+len(marker)+1)
+		}
+
+		wrapped := []string{}
+		current := ""
+		for _, word := range strings.Fields(line) {
+			if len(current) == 0 {
+				current = line[:indent] + word
+			} else if len([]rune(current))+1+len([]rune(word)) > config.MaxLineLength {
+				wrapped = append(wrapped, current)
+				current = continuation + word
+			} else {
+				current += " " + word
+			}
+		}
+		wrapped = append(wrapped, current)
+		return strings.Join(wrapped, "\n"), true
+	})
+}
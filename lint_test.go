@@ -0,0 +1,110 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// lintMessages runs the linter and returns the problems as strings.
+func lintMessages(content string, config LintConfig) []string {
+	messages := []string{}
+	for _, problem := range lintMarkdown(content, config) {
+		messages = append(messages, problem.String())
+	}
+	return messages
+}
+
+// TestLintMarkdown tests that each lint rule reports violations on the correct line,
+// and that disabled rules are not run.
+func TestLintMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		config  LintConfig
+		want    []string
+	}{
+		{
+			name:    "valid document",
+			content: "# Title\n\n## Usage\n\nSee [usage](#usage) and [main](tests/src/main.py).\n\n```bash\ngo build\n```",
+			config:  LintConfig{Root: ".", MaxLineLength: 80},
+			want:    []string{},
+		},
+		{
+			name:    "multiple and missing h1",
+			content: "# One\n\n# Two",
+			config:  LintConfig{},
+			want:    []string{`[lint] line 3: single-h1: multiple level one headings, found "Two"`},
+		},
+		{
+			name:    "no h1",
+			content: "## Two",
+			config:  LintConfig{},
+			want:    []string{"[lint] single-h1: document does not contain a level one heading"},
+		},
+		{
+			name:    "skipped heading level",
+			content: "# Title\n\n### Skipped\n\n```\n#### not a heading\n```",
+			config:  LintConfig{Disabled: []string{"fenced-code-language"}},
+			want:    []string{"[lint] line 3: heading-increment: heading level 3 follows level 1"},
+		},
+		{
+			name:    "code block without language",
+			content: "# Title\n\n```\ncode\n```",
+			config:  LintConfig{},
+			want:    []string{"[lint] line 3: fenced-code-language: code block does not have a language tag"},
+		},
+		{
+			name:    "broken links",
+			content: "# Title\n\n[a](#missing) [b](docs/missing.md) [c](https://example.com/missing)",
+			config:  LintConfig{Root: "."},
+			want: []string{
+				"[lint] line 3: no-broken-links: anchor #missing does not match any heading",
+				"[lint] line 3: no-broken-links: relative link docs/missing.md does not exist",
+			},
+		},
+		{
+			name:    "long prose line",
+			content: "# Title\n\n" + strings.Repeat("word ", 10) + "\n\nhttps://example.com/" + strings.Repeat("a", 40),
+			config:  LintConfig{MaxLineLength: 20},
+			want:    []string{"[lint] line 3: line-length: line length 50 exceeds maximum of 20"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := lintMessages(test.content, test.config)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got: %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestHeadingAnchors tests that headingAnchors generates GitHub compatible
+// anchors, including numbered anchors for duplicate headings.
+func TestHeadingAnchors(t *testing.T) {
+	anchors := headingAnchors("# Hello, World!\n\n## Usage\n\n## Usage\n\n### `goreadme test`")
+
+	for _, anchor := range []string{"hello-world", "usage", "usage-1", "goreadme-test"} {
+		if !anchors[anchor] {
+			t.Errorf("expected anchor %s in %v", anchor, anchors)
+		}
+	}
+}
+
+// TestFixMarkdown tests that fixMarkdown fixes all violations of the fixable rules.
+func TestFixMarkdown(t *testing.T) {
+	content := "# Title\n\n### Skipped\n\n# Second\n\n```\ncode\n```\n\n- a list item with enough words to wrap\n"
+	config := LintConfig{MaxLineLength: 20}
+
+	fixed := fixMarkdown(content, config)
+	expected := "# Title\n\n## Skipped\n\n## Second\n\n```text\ncode\n```\n\n- a list item with\n  enough words to\n  wrap\n"
+	if fixed != expected {
+		t.Errorf("got: %q, want: %q", fixed, expected)
+	}
+
+	if problems := lintMessages(fixed, config); len(problems) > 0 {
+		t.Errorf("expected no problems after fix, got %v", problems)
+	}
+}
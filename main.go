@@ -3,13 +3,21 @@ package main
 import (
 	"context"
 	"os"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v3"
 )
 
 func main() {
-	cmd := cli.Command{
+	if err := newCommand().Run(context.Background(), os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newCommand creates the goreadme command with every subcommand and flag.
+func newCommand() *cli.Command {
+	return &cli.Command{
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "log-level",
@@ -21,34 +29,426 @@ func main() {
 				Value: getDefaultConfigPath(),
 				Usage: "path to configuration file",
 			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "name of the config profile to use (defaults to GOREADME_PROFILE or the default profile)",
+			},
+			&cli.StringFlag{
+				Name:  "access-token",
+				Usage: "ChatGPT access token, overriding the config file and environment",
+			},
+			&cli.StringFlag{
+				Name:  "model",
+				Usage: "ChatGPT model, overriding the config file and environment",
+			},
+			&cli.StringFlag{
+				Name:  "assistant-id",
+				Usage: "ChatGPT assistant ID, overriding the config file and environment",
+			},
+			&cli.StringFlag{
+				Name:  "vector-store-id",
+				Usage: "ChatGPT vector store ID, overriding the config file and environment",
+			},
+			&cli.StringFlag{
+				Name:  "audit-log",
+				Usage: "path of the JSONL audit log recording every request sent to ChatGPT (defaults to GOREADME_AUDIT_LOG)",
+			},
+			&cli.StringFlag{
+				Name:  "audit-log-max-size",
+				Usage: "size after which the audit log is rotated, e.g. 10MB (0 to disable rotation)",
+			},
+			&cli.IntFlag{
+				Name:  "audit-log-max-backups",
+				Value: DefaultAuditLogMaxBackups,
+				Usage: "number of rotated audit logs that are kept",
+			},
+			&cli.StringFlag{
+				Name:  "record",
+				Usage: "path of a fixture file every request sent to ChatGPT is recorded to, with access tokens scrubbed (defaults to GOREADME_RECORD)",
+			},
+			&cli.StringFlag{
+				Name:  "replay",
+				Usage: "path of a fixture file requests are answered from instead of ChatGPT (defaults to GOREADME_REPLAY)",
+			},
 		},
+		Before: configureClients,
 		Commands: []*cli.Command{
 			{
-				Name:   "configure",
-				Usage:  "Configure chatgpt access",
+				Name:  "configure",
+				Usage: "Configure chatgpt access",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "token",
+						Usage: "ChatGPT access token. the command runs without prompting if provided",
+					},
+					&cli.StringFlag{
+						Name:  "token-store",
+						Value: TokenStorePlaintext,
+						Usage: "where the access token is stored: plaintext, encrypted, env:<variable> or helper:<command>",
+					},
+					&cli.BoolFlag{
+						Name:  "non-interactive",
+						Usage: "run without prompting, using the values of flags and environment variables",
+					},
+					&cli.BoolFlag{
+						Name:  "create-missing",
+						Usage: "create the vector store and assistant if their IDs are not provided",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the configured and created resource IDs as JSON",
+					},
+				},
 				Action: ConfigureCLICommand,
 			},
 			{
-				Name:   "test",
-				Usage:  "Test configured chatgpt configuration",
+				Name:  "test",
+				Usage: "Check the config file and chatgpt configuration, reporting the result of each check",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "print the results of the checks as JSON",
+					},
+				},
 				Action: TestCLICommand,
 			},
 			{
 				Name:  "generate",
 				Usage: "Generate a new README using a provided codebase",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:  "target",
 						Value: ".",
 						Usage: "target directory containing source code for README generation",
 					},
-				},
+					&cli.StringFlag{
+						Name:  "audience",
+						Usage: "audience the README is written for",
+					},
+					&cli.StringFlag{
+						Name:  "tone",
+						Usage: "tone used when writing the README",
+					},
+					&cli.BoolFlag{
+						Name:  "raw",
+						Usage: "write the assistant output without removing code fences and other chatter",
+					},
+					&cli.StringFlag{
+						Name:  "validate",
+						Value: "warn",
+						Usage: "validation of the generated README (off, warn or fail)",
+					},
+					&cli.BoolFlag{
+						Name:  "repair",
+						Usage: "ask the assistant to fix any problems found while validating the README",
+					},
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "automatically fix markdown lint violations where possible",
+					},
+					&cli.StringSliceFlag{
+						Name:  "disable-lint",
+						Usage: "name of a markdown lint rule to disable (can be repeated)",
+					},
+					&cli.IntFlag{
+						Name:  "max-line-length",
+						Value: 120,
+						Usage: "maximum length of prose lines in the README (0 to disable)",
+					},
+					&cli.BoolFlag{
+						Name:  "check-urls",
+						Usage: "check that external links in the README can be reached",
+					},
+					&cli.DurationFlag{
+						Name:  "url-timeout",
+						Value: 10 * time.Second,
+						Usage: "timeout for each request made when checking external links",
+					},
+					&cli.BoolFlag{
+						Name:  "sync-instructions",
+						Usage: "update the instructions of the assistant from the prompt template before generating",
+					},
+					&cli.BoolFlag{
+						Name:  "run-vector-store",
+						Usage: "index the uploaded files in a vector store created for this run, which expires automatically",
+					},
+					&cli.BoolFlag{
+						Name:  "yes",
+						Usage: "upload the files without listing them for approval, e.g. when running in CI",
+					},
+				}, settingsFlags()...),
 				Action: GenerateCLICommand,
 			},
+			{
+				Name:  "check",
+				Usage: "Validate an existing README against the source code",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "target",
+						Value: ".",
+						Usage: "target directory containing the source code the README documents",
+					},
+					&cli.StringFlag{
+						Name:  "file",
+						Usage: "path to the README to check (defaults to README.md in the target directory)",
+					},
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "automatically fix markdown lint violations where possible",
+					},
+					&cli.StringSliceFlag{
+						Name:  "disable-lint",
+						Usage: "name of a markdown lint rule to disable (can be repeated)",
+					},
+					&cli.IntFlag{
+						Name:  "max-line-length",
+						Value: 120,
+						Usage: "maximum length of prose lines in the README (0 to disable)",
+					},
+					&cli.BoolFlag{
+						Name:  "check-urls",
+						Usage: "check that external links in the README can be reached",
+					},
+					&cli.DurationFlag{
+						Name:  "url-timeout",
+						Value: 10 * time.Second,
+						Usage: "timeout for each request made when checking external links",
+					},
+				},
+				Action: CheckCLICommand,
+			},
+			{
+				Name:  "prompt",
+				Usage: "Inspect the prompt sent to the ChatGPT assistant",
+				Commands: []*cli.Command{
+					{
+						Name:  "show",
+						Usage: "Render the prompt for a target directory",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:  "target",
+								Value: ".",
+								Usage: "target directory containing source code for README generation",
+							},
+							&cli.StringFlag{
+								Name:  "audience",
+								Usage: "audience the README is written for",
+							},
+							&cli.StringFlag{
+								Name:  "tone",
+								Usage: "tone used when writing the README",
+							},
+						}, settingsFlags()...),
+						Action: PromptShowCLICommand,
+					},
+				},
+			},
+			{
+				Name:  "assistant",
+				Usage: "Manage the ChatGPT assistants used by goreadme",
+				Commands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "List the assistants created by goreadme",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "list every assistant of the account",
+							},
+						},
+						Action: AssistantListCLICommand,
+					},
+					{
+						Name:      "show",
+						Usage:     "Print the details of an assistant, or the configured assistant",
+						ArgsUsage: "[assistant-id]",
+						Action:    AssistantShowCLICommand,
+					},
+					{
+						Name:      "update",
+						Usage:     "Change the model, instructions or vector stores of an assistant, or the configured assistant",
+						ArgsUsage: "[assistant-id]",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "instructions",
+								Usage: "instructions of the assistant",
+							},
+							&cli.StringFlag{
+								Name:  "instructions-file",
+								Usage: "path of a file containing the instructions of the assistant",
+							},
+							&cli.BoolFlag{
+								Name:  "sync-instructions",
+								Usage: "set the instructions of the assistant from the prompt template of the target directory",
+							},
+							&cli.StringFlag{
+								Name:  "target",
+								Value: ".",
+								Usage: "target directory used to find the prompt template",
+							},
+							&cli.StringFlag{
+								Name:  "prompt-template",
+								Usage: "path of the prompt template, relative to the target directory",
+							},
+							&cli.StringSliceFlag{
+								Name:  "vector-store",
+								Usage: "ID of a vector store attached to the assistant, replacing the attached vector stores (can be repeated)",
+							},
+						},
+						Action: AssistantUpdateCLICommand,
+					},
+					{
+						Name:      "delete",
+						Usage:     "Delete an assistant",
+						ArgsUsage: "<assistant-id>",
+						Action:    AssistantDeleteCLICommand,
+					},
+				},
+			},
+			{
+				Name:  "vectorstore",
+				Usage: "Manage the ChatGPT vector stores used by goreadme",
+				Commands: []*cli.Command{
+					{
+						Name:  "list",
+						Usage: "List the vector stores created by goreadme",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "list every vector store of the account",
+							},
+						},
+						Action: VectorStoreListCLICommand,
+					},
+					{
+						Name:      "show",
+						Usage:     "Print the details of a vector store, or the configured vector store",
+						ArgsUsage: "[vector-store-id]",
+						Action:    VectorStoreShowCLICommand,
+					},
+					{
+						Name:      "files",
+						Usage:     "List the files of a vector store, or the configured vector store",
+						ArgsUsage: "[vector-store-id]",
+						Action:    VectorStoreFilesCLICommand,
+					},
+					{
+						Name:      "purge",
+						Usage:     "Detach every file from a vector store, or the configured vector store using --yes",
+						ArgsUsage: "[vector-store-id]",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "yes",
+								Usage: "purge the configured vector store when no vector store is passed",
+							},
+							&cli.BoolFlag{
+								Name:  "delete-files",
+								Usage: "delete the detached files as well, even if they are used by other vector stores or assistants",
+							},
+						},
+						Action: VectorStorePurgeCLICommand,
+					},
+					{
+						Name:      "delete",
+						Usage:     "Delete a vector store",
+						ArgsUsage: "<vector-store-id>",
+						Action:    VectorStoreDeleteCLICommand,
+					},
+				},
+			},
+			{
+				Name:  "config",
+				Usage: "Inspect the goreadme configuration",
+				Commands: []*cli.Command{
+					{
+						Name:  "show",
+						Usage: "Print the global config, or the effective settings for a target directory",
+						Flags: append([]cli.Flag{
+							&cli.StringFlag{
+								Name:  "target",
+								Value: ".",
+								Usage: "target directory used to resolve the project config",
+							},
+							&cli.BoolFlag{
+								Name:  "resolved",
+								Usage: "print the effective settings and the source of each setting",
+							},
+						}, settingsFlags()...),
+						Action: ConfigShowCLICommand,
+					},
+					{
+						Name:   "list",
+						Usage:  "List the profiles of the config file",
+						Action: ConfigListCLICommand,
+					},
+					{
+						Name:   "migrate",
+						Usage:  "Upgrade the config file to the current schema version, keeping a backup of the original",
+						Action: ConfigMigrateCLICommand,
+					},
+				},
+			},
 		},
 	}
+}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+// configureClients configures the audit log and transport of the ChatGPT clients created
+// by the command before it runs.
+func configureClients(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	ctx, err := configureAuditLog(ctx, cmd)
+	if err != nil {
+		return ctx, err
+	}
+	return configureTransport(ctx, cmd)
+}
+
+// settingsFlags returns the flags used to override the settings of the
+// global and project config files.
+func settingsFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "include",
+			Usage: "pattern of files to upload (can be repeated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude",
+			Usage: "pattern of files that are not uploaded (can be repeated)",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "path the README is written to, relative to the target directory",
+		},
+		&cli.StringFlag{
+			Name:  "prompt-template",
+			Usage: "path of the prompt template, relative to the target directory",
+		},
+		&cli.StringSliceFlag{
+			Name:  "section",
+			Usage: "section the README must contain (can be repeated)",
+		},
+		&cli.StringFlag{
+			Name:  "max-file-size",
+			Usage: "maximum size of a single uploaded file, e.g. 512KB",
+		},
+		&cli.StringFlag{
+			Name:  "max-total-size",
+			Usage: "maximum size of all uploaded files, e.g. 5MB",
+		},
+		&cli.StringFlag{
+			Name:  "concurrency",
+			Usage: "number of files uploaded or deleted at the same time (default: 5)",
+		},
+		&cli.BoolFlag{
+			Name:  "follow-symlinks",
+			Usage: "follow symlinks to files and directories when discovering files",
+		},
+		&cli.BoolFlag{
+			Name:  "same-filesystem",
+			Usage: "skip directories and files on a different filesystem to the target directory",
+		},
+		&cli.StringFlag{
+			Name:  "secrets",
+			Usage: "action taken when secrets are found in the files to upload: redact, abort or off (default: redact)",
+		},
 	}
 }
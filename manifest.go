@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const combinedFilePrefix = "combined_source_files"
+
+// ManifestEntry is a single file of the upload manifest, listing what leaves the machine.
+type ManifestEntry struct {
+	// Name is the name the file is uploaded with, used as the key of the files to upload
+	Name string
+	// Path is the path of the file relative to the target directory
+	Path string
+	Size int64
+	// Bucket is the combined file the file is uploaded in
+	Bucket string
+	// Secrets is the number of secrets that are redacted from the file
+	Secrets int
+}
+
+// UploadApproval is the result of asking the user to approve the upload manifest.
+type UploadApproval struct {
+	Approved bool
+	// Deselected are the files that the user removed from the upload
+	Deselected []ManifestEntry
+	// SaveExcludes is true if the deselected files should be saved as excludes of the project config
+	SaveExcludes bool
+}
+
+// combinedFilename returns the name of the combined file that files with the given extension are uploaded in.
+func combinedFilename(ext string) string {
+	return combinedFilePrefix + ext
+}
+
+// buildManifest lists the files to upload, sorted by bucket and then path.
+//
+// Parameters:
+//   - target: The target directory, used to make paths relative.
+//   - files: The files to upload, keyed by the name they are uploaded with.
+//   - findings: The secrets found in the files, counted against each file.
+//
+// Returns:
+//   - []ManifestEntry: The entries of the manifest.
+func buildManifest(target string, files map[string]SourceFile, findings []SecretFinding) []ManifestEntry {
+	secrets := map[string]int{}
+	for _, finding := range findings {
+		secrets[finding.Path]++
+	}
+
+	entries := []ManifestEntry{}
+	for name, file := range files {
+		relative, err := filepath.Rel(target, file.Path)
+		if err != nil {
+			relative = file.Path
+		}
+		entries = append(entries, ManifestEntry{
+			Name:    name,
+			Path:    filepath.ToSlash(relative),
+			Size:    file.Size,
+			Bucket:  combinedFilename(filepath.Ext(name)),
+			Secrets: secrets[file.Path],
+		})
+	}
+
+	slices.SortFunc(entries, func(a, b ManifestEntry) int {
+		if a.Bucket != b.Bucket {
+			return strings.Compare(a.Bucket, b.Bucket)
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return entries
+}
+
+// formatManifest formats the manifest as a numbered list, one file per line, followed by totals.
+func formatManifest(entries []ManifestEntry) string {
+	var builder strings.Builder
+	width := len(strconv.Itoa(len(entries)))
+
+	var total int64
+	buckets := map[string]bool{}
+	for i, entry := range entries {
+		line := fmt.Sprintf("  %*d  %-28s %10d bytes  %s", width, i+1, entry.Bucket, entry.Size, entry.Path)
+		if entry.Secrets > 0 {
+			line += fmt.Sprintf(" (%d secrets redacted)", entry.Secrets)
+		}
+		builder.WriteString(line + "\n")
+
+		total += entry.Size
+		buckets[entry.Bucket] = true
+	}
+	builder.WriteString(fmt.Sprintf("%d files, %d bytes in %d uploads\n", len(entries), total, len(buckets)))
+	return builder.String()
+}
+
+// parseSelection parses a selection of manifest entries, e.g. 1,3-5, into zero based indexes.
+//
+// Parameters:
+//   - value: The comma separated numbers and ranges of the selection, starting from 1.
+//   - count: The number of entries that can be selected.
+//
+// Returns:
+//   - []int: The sorted indexes of the selected entries, without duplicates.
+//   - error: An error if a number or range is invalid or outside of the entries.
+func parseSelection(value string, count int) ([]int, error) {
+	indexes := []int{}
+	for _, item := range splitList(value) {
+		first, last, isRange := strings.Cut(item, "-")
+
+		start, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %s", item)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+				return nil, fmt.Errorf("invalid selection %s", item)
+			}
+		}
+		if start < 1 || end > count || start > end {
+			return nil, fmt.Errorf("selection %s is outside of files 1 to %d", item, count)
+		}
+
+		for i := start; i <= end; i++ {
+			indexes = append(indexes, i-1)
+		}
+	}
+	slices.Sort(indexes)
+	return slices.Compact(indexes), nil
+}
+
+// approveUploads shows the upload manifest and asks the user to approve it. files can
+// be deselected before approving, and the deselected files can be saved as excludes so
+// they are never offered for upload again.
+//
+// Parameters:
+//   - reader: The reader the answers of the user are read from.
+//   - writer: The writer the manifest and prompts are written to.
+//   - entries: The entries of the upload manifest.
+//
+// Returns:
+//   - UploadApproval: Whether the upload was approved, and the files that were deselected.
+//   - error: An error if the answers cannot be read.
+func approveUploads(reader *bufio.Reader, writer io.Writer, entries []ManifestEntry) (UploadApproval, error) {
+	approval := UploadApproval{}
+	selected := slices.Clone(entries)
+
+	// deselect prompts for files to remove from the upload
+	deselect := func() error {
+		value, err := readAnswer(reader, writer, "Files to deselect (e.g. 1,3-5): ")
+		if err != nil {
+			return err
+		}
+		indexes, err := parseSelection(value, len(selected))
+		if err != nil {
+			fmt.Fprintln(writer, err)
+			return nil
+		}
+		for i := len(indexes) - 1; i >= 0; i-- {
+			approval.Deselected = append(approval.Deselected, selected[indexes[i]])
+			selected = slices.Delete(selected, indexes[i], indexes[i]+1)
+		}
+		return nil
+	}
+
+	for {
+		fmt.Fprintf(writer, "The following files will be uploaded to ChatGPT:\n%s", formatManifest(selected))
+		answer, err := readAnswer(reader, writer, "Upload these files? [y]es, [n]o, [d]eselect files, [s]ave deselected files as excludes and upload: ")
+		if err != nil {
+			return approval, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			approval.Approved = len(selected) > 0
+			return approval, nil
+		case "n", "no":
+			return approval, nil
+		case "d", "deselect":
+			if err := deselect(); err != nil {
+				return approval, err
+			}
+		case "s", "save":
+			if len(approval.Deselected) == 0 {
+				if err := deselect(); err != nil {
+					return approval, err
+				}
+			}
+			if len(approval.Deselected) == 0 {
+				continue
+			}
+			approval.Approved = len(selected) > 0
+			approval.SaveExcludes = true
+			return approval, nil
+		default:
+			fmt.Fprintf(writer, "unknown answer %s\n", answer)
+		}
+	}
+}
+
+// readAnswer prompts for and reads a single line, failing if the input ends before an answer is given.
+func readAnswer(reader *bufio.Reader, writer io.Writer, prompt string) (string, error) {
+	fmt.Fprint(writer, prompt)
+	value, err := reader.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || len(value) == 0) {
+		return "", err
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// excludePattern returns the exclude pattern matching only the file at the relative path.
+// the pattern is anchored to the target directory, and the characters that path.Match
+// treats as patterns are escaped.
+func excludePattern(relative string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`)
+	return "/" + replacer.Replace(relative)
+}
+
+// excludePatterns returns the exclude patterns of the entries. patterns match the path of
+// each file on disk, so renamed files such as .vue files are excluded on later runs.
+func excludePatterns(entries []ManifestEntry) []string {
+	patterns := []string{}
+	for _, entry := range entries {
+		patterns = append(patterns, excludePattern(entry.Path))
+	}
+	return patterns
+}
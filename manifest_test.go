@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestManifest creates a manifest of a python file, a go file and a file whose name
+// contains pattern characters.
+func newTestManifest() []ManifestEntry {
+	files := map[string]SourceFile{
+		"project/main.go":     {Path: "project/main.go", Size: 120},
+		"project/src/a.py":    {Path: "project/src/a.py", Size: 40},
+		"project/src/[id].py": {Path: "project/src/[id].py", Size: 10},
+	}
+	return buildManifest("project", files, []SecretFinding{
+		{Path: "project/src/a.py", Line: 1, Rule: "aws-key"},
+		{Path: "project/src/a.py", Line: 4, Rule: "jwt"},
+	})
+}
+
+// TestBuildManifest tests that the manifest lists every file with the combined file it
+// is uploaded in, sorted by bucket and path, and counts the redacted secrets of each file.
+func TestBuildManifest(t *testing.T) {
+	entries := newTestManifest()
+
+	expected := []ManifestEntry{
+		{Name: "project/main.go", Path: "main.go", Size: 120, Bucket: "combined_source_files.go"},
+		{Name: "project/src/[id].py", Path: "src/[id].py", Size: 10, Bucket: "combined_source_files.py"},
+		{Name: "project/src/a.py", Path: "src/a.py", Size: 40, Bucket: "combined_source_files.py", Secrets: 2},
+	}
+	if !slices.Equal(entries, expected) {
+		t.Errorf("got: %+v, want: %+v", entries, expected)
+	}
+
+	formatted := formatManifest(entries)
+	for _, want := range []string{"3 files, 170 bytes in 2 uploads", "src/a.py (2 secrets redacted)"} {
+		if !strings.Contains(formatted, want) {
+			t.Errorf("got: %s, want: %s", formatted, want)
+		}
+	}
+}
+
+// TestParseSelection tests parsing numbers and ranges of manifest entries.
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []int
+		valid    bool
+	}{
+		{value: "1", expected: []int{0}, valid: true},
+		{value: "3-5, 1", expected: []int{0, 2, 3, 4}, valid: true},
+		{value: "2,2-3", expected: []int{1, 2}, valid: true},
+		{value: "0", valid: false},
+		{value: "4-2", valid: false},
+		{value: "6", valid: false},
+		{value: "a", valid: false},
+	}
+
+	for _, test := range tests {
+		indexes, err := parseSelection(test.value, 5)
+		if test.valid != (err == nil) {
+			t.Errorf("%s got: %v, want valid: %v", test.value, err, test.valid)
+		}
+		if test.valid && !slices.Equal(indexes, test.expected) {
+			t.Errorf("%s got: %v, want: %v", test.value, indexes, test.expected)
+		}
+	}
+}
+
+// TestApproveUploads tests approving, rejecting and deselecting files of the manifest.
+func TestApproveUploads(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		approved   bool
+		deselected []string
+		save       bool
+	}{
+		{name: "approve", input: "y\n", approved: true},
+		{name: "reject", input: "n\n", approved: false},
+		{name: "deselect", input: "d\n1,3\nyes\n", approved: true, deselected: []string{"src/a.py", "main.go"}},
+		{name: "invalid selection", input: "d\n9\nd\n2\ny\n", approved: true, deselected: []string{"src/[id].py"}},
+		{name: "deselect everything", input: "d\n1-3\ny\n", approved: false, deselected: []string{"src/a.py", "src/[id].py", "main.go"}},
+		{name: "save", input: "s\n2\n", approved: true, deselected: []string{"src/[id].py"}, save: true},
+		{name: "save after deselect", input: "x\nd\n1\ns\n", approved: true, deselected: []string{"main.go"}, save: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			approval, err := approveUploads(bufio.NewReader(strings.NewReader(test.input)), io.Discard, newTestManifest())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			deselected := []string{}
+			for _, entry := range approval.Deselected {
+				deselected = append(deselected, entry.Path)
+			}
+			if approval.Approved != test.approved || approval.SaveExcludes != test.save || !slices.Equal(deselected, test.deselected) {
+				t.Errorf("got: %+v, want: approved %v, save %v, deselected %v", approval, test.approved, test.save, test.deselected)
+			}
+		})
+	}
+
+	// the upload is never approved if the input ends without an answer
+	if _, err := approveUploads(bufio.NewReader(strings.NewReader("")), io.Discard, newTestManifest()); err == nil {
+		t.Error("expected error when the input ends before an answer is given")
+	}
+}
+
+// TestAddProjectExcludes tests that excludes are added to the project config without
+// changing its other settings, and that the config file is created if it does not exist.
+func TestAddProjectExcludes(t *testing.T) {
+	target := t.TempDir()
+	patterns := []string{excludePattern("src/[id].py"), excludePattern("main.go")}
+
+	if err := addProjectExcludes(target, patterns); err != nil {
+		t.Fatal(err)
+	}
+	project, err := loadProjectConfig(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(project.Exclude, patterns) {
+		t.Errorf("got: %v, want: %v", project.Exclude, patterns)
+	}
+
+	path := filepath.Join(target, ProjectConfigName)
+	existing := "# project settings\nmodel: gpt-4o\nexclude: [\"tests/\", \"/main.go\"]\n"
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := addProjectExcludes(target, patterns); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err = loadProjectConfig(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"tests/", "/main.go", `/src/\[id].py`}
+	if project.Model != "gpt-4o" || !slices.Equal(project.Exclude, expected) {
+		t.Errorf("got: %s %v, want: %s %v", project.Model, project.Exclude, "gpt-4o", expected)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(contents), "# project settings") {
+		t.Errorf("expected comments of the project config to be kept, got: %s", contents)
+	}
+
+	// saved excludes only match the deselected files
+	settings := newSettings()
+	settings.Exclude = project.Exclude
+	files := map[string]SourceFile{
+		"project/main.go":     {Path: "project/main.go"},
+		"project/cmd/main.go": {Path: "project/cmd/main.go"},
+		"project/src/[id].py": {Path: "project/src/[id].py"},
+		"project/src/i.py":    {Path: "project/src/i.py"},
+	}
+	filtered, err := settings.filterFiles("project", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relativePaths("project", filtered); !slices.Equal(got, []string{"cmd/main.go", "src/i.py"}) {
+		t.Errorf("got: %v, want: %v", got, []string{"cmd/main.go", "src/i.py"})
+	}
+}
+
+// TestSavedExcludesFilterFiles tests that the excludes saved for deselected files exclude
+// the same files on the next run, including files uploaded with a different extension.
+func TestSavedExcludesFilterFiles(t *testing.T) {
+	target := t.TempDir()
+	files := map[string]SourceFile{
+		filepath.Join(target, "src", "App.vue.txt"): {Path: filepath.Join(target, "src", "App.vue")},
+		filepath.Join(target, "src", "view.js"):     {Path: filepath.Join(target, "src", "view.jsx")},
+		filepath.Join(target, "src", "index.tx"):    {Path: filepath.Join(target, "src", "index.tsx")},
+		filepath.Join(target, "main.go"):            {Path: filepath.Join(target, "main.go")},
+	}
+
+	entries := buildManifest(target, files, nil)
+	selection := []string{}
+	for i, entry := range entries {
+		if entry.Path != "main.go" {
+			selection = append(selection, strconv.Itoa(i+1))
+		}
+	}
+	input := fmt.Sprintf("s\n%s\n", strings.Join(selection, ","))
+	approval, err := approveUploads(bufio.NewReader(strings.NewReader(input)), io.Discard, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addProjectExcludes(target, excludePatterns(approval.Deselected)); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := loadProjectConfig(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := newSettings()
+	settings.Exclude = project.Exclude
+	filtered, err := settings.filterFiles(target, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relativePaths(target, filtered); !slices.Equal(got, []string{"main.go"}) {
+		t.Errorf("got: %v, want: %v", got, []string{"main.go"})
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ConfigVersion is the version of the config file schema written by this version of goreadme.
+const ConfigVersion = 2
+
+// outdatedWarnings contains the paths of the config files that warnIfOutdated has warned
+// about, so each outdated file is only warned about once per process.
+var outdatedWarnings sync.Map
+
+// warnIfOutdated logs a warning if the config file uses an older version of the schema.
+// outdated files are upgraded in memory when they are read, and only rewritten by
+// goreadme configure or goreadme config migrate.
+func warnIfOutdated(path string, version int) {
+	if version >= ConfigVersion {
+		return
+	}
+	if _, warned := outdatedWarnings.LoadOrStore(path, true); warned {
+		return
+	}
+	log.Warn(fmt.Sprintf("config file %s uses version %d, run goreadme config migrate to upgrade it to version %d", path, version, ConfigVersion))
+}
+
+// ConfigMigration upgrades the decoded JSON of a config file from one schema version to the next.
+type ConfigMigration func(raw map[string]any) (map[string]any, error)
+
+// configMigrations maps each schema version to the migration that upgrades config
+// files of that version to the next version.
+var configMigrations = map[int]ConfigMigration{
+	1: migrateConfigV1,
+}
+
+// migrateConfigV1 moves the fields of a version 1 config file, which holds a single
+// config, into the default profile of a version 2 config file.
+func migrateConfigV1(raw map[string]any) (map[string]any, error) {
+	profile := map[string]any{}
+	for key, value := range raw {
+		if key != "version" {
+			profile[key] = value
+		}
+	}
+
+	return map[string]any{
+		"version":        2,
+		"defaultProfile": DefaultProfileName,
+		"profiles": map[string]any{
+			DefaultProfileName: profile,
+		},
+	}, nil
+}
+
+// configVersion returns the schema version of the decoded JSON of a config file. config
+// files written before the version field was added are version 1 if they hold a single
+// config, and version 2 if they hold profiles.
+func configVersion(raw map[string]any) (int, error) {
+	value, ok := raw["version"]
+	if !ok {
+		if _, ok := raw["profiles"]; ok {
+			return 2, nil
+		}
+		return 1, nil
+	}
+
+	version, ok := value.(float64)
+	if !ok || version != float64(int(version)) || version < 1 {
+		return 0, fmt.Errorf("field version must be a positive integer, got %v", value)
+	}
+	return int(version), nil
+}
+
+// migrateConfig upgrades the decoded JSON of a config file to the current schema version
+// by applying the migrations of each version in turn.
+//
+// Parameters:
+//   - raw: The decoded JSON of the config file.
+//
+// Returns:
+//   - map[string]any: The upgraded config file.
+//   - int: The version of the config file before it was upgraded.
+//   - error: An error if the version is invalid, newer than the current version, or a migration fails.
+func migrateConfig(raw map[string]any) (map[string]any, int, error) {
+	original, err := configVersion(raw)
+	if err != nil {
+		return raw, 0, err
+	}
+	if original > ConfigVersion {
+		return raw, original, fmt.Errorf("config version %d is newer than the supported version %d, please upgrade goreadme", original, ConfigVersion)
+	}
+
+	for version := original; version < ConfigVersion; version++ {
+		migration, ok := configMigrations[version]
+		if !ok {
+			return raw, original, fmt.Errorf("no migration found from config version %d", version)
+		}
+
+		raw, err = migration(raw)
+		if err != nil {
+			return raw, original, fmt.Errorf("error migrating config from version %d: %w", version, err)
+		}
+		log.Debug(fmt.Sprintf("migrated config from version %d to %d", version, version+1))
+	}
+	raw["version"] = ConfigVersion
+	return raw, original, nil
+}
+
+// upgradeConfigFile writes the upgraded config file to disk, after copying the original
+// contents to a backup next to the config file named after the original version.
+//
+// Parameters:
+//   - path: The path of the config file.
+//   - original: The contents of the config file before it was upgraded.
+//   - version: The version of the config file before it was upgraded.
+//   - raw: The upgraded config file.
+//
+// Returns:
+//   - string: The path of the backup.
+//   - error: An error if the backup or the upgraded config file cannot be written.
+func upgradeConfigFile(path string, original []byte, version int, raw map[string]any) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := writePrivateFile(backup, original); err != nil {
+		return backup, err
+	}
+
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return backup, err
+	}
+	if err := writePrivateFile(path, data); err != nil {
+		return backup, err
+	}
+	return backup, nil
+}
+
+// decodeConfigFile decodes the contents of a config file, upgrading files of older schema
+// versions in memory. the file is never written, see migrateConfigFile.
+//
+// Parameters:
+//   - contents: The contents of the config file.
+//
+// Returns:
+//   - ConfigFile: The decoded and upgraded config file.
+//   - int: The version of the config file before it was upgraded.
+//   - error: An error if the contents are invalid JSON or cannot be upgraded.
+func decodeConfigFile(contents []byte) (ConfigFile, int, error) {
+	var file ConfigFile
+
+	raw := map[string]any{}
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		return file, 0, err
+	}
+
+	upgraded, version, err := migrateConfig(raw)
+	if err != nil {
+		return file, version, err
+	}
+
+	data, err := json.Marshal(upgraded)
+	if err != nil {
+		return file, version, err
+	}
+
+	err = json.Unmarshal(data, &file)
+	return file, version, err
+}
+
+// migrateConfigFile upgrades the config file at the path to the current schema version
+// and writes it back to disk, keeping a backup of the original. Config files that already
+// use the current version are not changed.
+//
+// Parameters:
+//   - path: The path of the config file.
+//
+// Returns:
+//   - int: The version of the config file before it was upgraded.
+//   - string: The path of the backup, or an empty string if the file was not upgraded.
+//   - error: An error if the config file cannot be read, decoded or written.
+func migrateConfigFile(path string) (int, string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	raw := map[string]any{}
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		return 0, "", err
+	}
+	upgraded, version, err := migrateConfig(raw)
+	if err != nil || version == ConfigVersion {
+		return version, "", err
+	}
+
+	backup, err := upgradeConfigFile(path, contents, version, upgraded)
+	if err != nil {
+		return version, "", err
+	}
+	log.Info(fmt.Sprintf("upgraded config file %s from version %d to %d, the original was saved to %s", path, version, ConfigVersion, backup))
+	return version, backup, nil
+}
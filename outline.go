@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OutlineSection is a section the generated README must contain, along with
+// guidance for the assistant about what the section should cover.
+type OutlineSection struct {
+	Name     string `yaml:"name"`
+	Guidance string `yaml:"guidance,omitempty"`
+	// Aliases are alternative headings that also satisfy the section, e.g. Setup for Installation
+	Aliases []string `yaml:"aliases,omitempty"`
+	// Optional sections are included in the prompt but are not required to be present
+	Optional bool `yaml:"optional,omitempty"`
+}
+
+// Matches checks if the heading text matches the name or any of the aliases of the
+// section. headings are compared using their anchors, so case and punctuation are ignored.
+func (section OutlineSection) Matches(heading string) bool {
+	slug := headingSlug(heading)
+	for _, name := range append([]string{section.Name}, section.Aliases...) {
+		if headingSlug(name) == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// buildOutline creates the outline of the README. sections passed on the command
+// line replace the sections of the project config, keeping the guidance of any
+// configured section with the same name.
+func buildOutline(configured []OutlineSection, names []string) []OutlineSection {
+	if len(names) == 0 {
+		return configured
+	}
+
+	outline := []OutlineSection{}
+	for _, name := range names {
+		section := OutlineSection{Name: name}
+		for _, existing := range configured {
+			if existing.Matches(name) {
+				section = existing
+				break
+			}
+		}
+		outline = append(outline, section)
+	}
+	return outline
+}
+
+// findSectionHeading returns the index of the first heading that matches the section.
+func findSectionHeading(headings []Heading, section OutlineSection) int {
+	return slices.IndexFunc(headings, func(heading Heading) bool {
+		return heading.Level > 1 && section.Matches(heading.Text)
+	})
+}
+
+// findMissingSections returns the required sections of the outline that do not
+// have a matching heading in the README. the level one title is never matched.
+func findMissingSections(content string, outline []OutlineSection) []OutlineSection {
+	headings := parseHeadings(content)
+
+	missing := []OutlineSection{}
+	for _, section := range outline {
+		if !section.Optional && findSectionHeading(headings, section) < 0 {
+			missing = append(missing, section)
+		}
+	}
+	return missing
+}
+
+// newSectionsCheck creates a check that reports required sections of the outline
+// that are missing from the README.
+func newSectionsCheck(outline []OutlineSection) ReadmeCheck {
+	return ReadmeCheck{
+		Name:     "sections",
+		Guidance: "The README must contain a heading for every required section of the outline.",
+		Run: func(content string) ([]Problem, error) {
+			problems := []Problem{}
+			for _, section := range findMissingSections(content, outline) {
+				problems = append(problems, Problem{
+					Check:   "sections",
+					Message: fmt.Sprintf("missing required section %q", section.Name),
+				})
+			}
+			return problems, nil
+		},
+	}
+}
+
+// missingSectionsInstructions creates the message asking the assistant to write
+// only the sections that are missing from the README it generated.
+func missingSectionsInstructions(missing []OutlineSection) string {
+	var builder strings.Builder
+	builder.WriteString("The README you generated is missing the following sections:\n\n")
+	for _, section := range missing {
+		builder.WriteString(fmt.Sprintf("- %s", section.Name))
+		if len(section.Guidance) > 0 {
+			builder.WriteString(": " + section.Guidance)
+		}
+		builder.WriteString("\n")
+	}
+	builder.WriteString("\nPlease reply with only these sections, each starting with a level two heading using the section name exactly as given. Do not repeat any other part of the README.")
+	return builder.String()
+}
+
+// sectionBounds returns the line range of the section starting at the heading with
+// the given index. the section ends before the next heading of the same or a higher level.
+func sectionBounds(lines []string, headings []Heading, index int) (int, int) {
+	start := headings[index].Line - 1
+	for _, heading := range headings[index+1:] {
+		if heading.Level <= headings[index].Level {
+			return start, heading.Line - 1
+		}
+	}
+	return start, len(lines)
+}
+
+// extractSection returns the content of the section matching the outline section,
+// including its heading, from a markdown document.
+func extractSection(content string, section OutlineSection) (Heading, string, bool) {
+	headings := parseHeadings(content)
+	index := findSectionHeading(headings, section)
+	if index < 0 {
+		return Heading{}, "", false
+	}
+
+	lines := strings.Split(content, "\n")
+	start, end := sectionBounds(lines, headings, index)
+	return headings[index], strings.TrimSpace(strings.Join(lines[start:end], "\n")), true
+}
+
+// insertSections adds the missing sections written by the assistant to the README.
+// each section is inserted after the closest preceding section of the outline that is
+// present in the README, or before the closest following section if there is none, and
+// is appended to the end of the README otherwise. the headings of inserted sections are
+// shifted to the level used by the other sections of the outline.
+//
+// Parameters:
+//   - content: The README content.
+//   - generated: The assistant output containing the missing sections.
+//   - outline: The outline of the README.
+//
+// Returns:
+//   - string: The README containing the inserted sections.
+//   - []OutlineSection: The sections that could not be found in the assistant output.
+func insertSections(content, generated string, outline []OutlineSection) (string, []OutlineSection) {
+	notFound := []OutlineSection{}
+
+	for position, section := range outline {
+		if findSectionHeading(parseHeadings(content), section) >= 0 {
+			continue
+		}
+
+		heading, text, ok := extractSection(generated, section)
+		if !ok {
+			if !section.Optional {
+				notFound = append(notFound, section)
+			}
+			continue
+		}
+
+		headings := parseHeadings(content)
+		lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+
+		// find the level used by the sections already in the README and
+		// where the new section should be inserted
+		level, insert, found := 2, len(lines), false
+		for i := position - 1; i >= 0 && !found; i-- {
+			if index := findSectionHeading(headings, outline[i]); index >= 0 {
+				_, insert = sectionBounds(lines, headings, index)
+				level, found = headings[index].Level, true
+			}
+		}
+		if !found {
+			for _, next := range outline[position+1:] {
+				if index := findSectionHeading(headings, next); index >= 0 {
+					insert = headings[index].Line - 1
+					level = headings[index].Level
+					break
+				}
+			}
+		}
+
+		text = rewriteHeadings(text, func(h Heading) int {
+			return min(max(h.Level-heading.Level+level, 1), 6)
+		})
+
+		before := strings.TrimSpace(strings.Join(lines[:insert], "\n"))
+		after := strings.TrimSpace(strings.Join(lines[insert:], "\n"))
+		parts := slices.DeleteFunc([]string{before, text, after}, func(part string) bool {
+			return len(part) == 0
+		})
+		content = strings.Join(parts, "\n\n") + "\n"
+	}
+	return content, notFound
+}
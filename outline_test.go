@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// sectionNames returns the names of the provided sections.
+func sectionNames(sections []OutlineSection) []string {
+	names := []string{}
+	for _, section := range sections {
+		names = append(names, section.Name)
+	}
+	return names
+}
+
+// TestFindMissingSections tests that sections are matched by name and aliases,
+// ignoring case and punctuation, and that optional sections are not reported.
+func TestFindMissingSections(t *testing.T) {
+	outline := []OutlineSection{
+		{Name: "Installation", Aliases: []string{"Setup"}},
+		{Name: "Configuration"},
+		{Name: "API"},
+		{Name: "Contributing", Optional: true},
+		{Name: "License"},
+	}
+	content := "# License\n\n## setup\n\n### Configuration:\n\n```markdown\n## API\n```"
+
+	got := sectionNames(findMissingSections(content, outline))
+	want := []string{"API", "License"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+// TestBuildOutline tests that section flags replace the configured outline while
+// keeping the guidance of configured sections.
+func TestBuildOutline(t *testing.T) {
+	configured := []OutlineSection{
+		{Name: "Installation", Guidance: "install steps"},
+		{Name: "Usage"},
+	}
+
+	if got := buildOutline(configured, nil); !slices.Equal(sectionNames(got), []string{"Installation", "Usage"}) {
+		t.Errorf("got: %v, want: %v", sectionNames(got), []string{"Installation", "Usage"})
+	}
+
+	got := buildOutline(configured, []string{"License", "installation"})
+	if !slices.Equal(sectionNames(got), []string{"License", "Installation"}) || got[1].Guidance != "install steps" {
+		t.Errorf("got: %+v", got)
+	}
+}
+
+// TestInsertSections tests that missing sections are inserted in outline order,
+// with their headings shifted to the level of the existing sections.
+func TestInsertSections(t *testing.T) {
+	outline := []OutlineSection{
+		{Name: "Installation"},
+		{Name: "Configuration"},
+		{Name: "Usage"},
+		{Name: "License"},
+		{Name: "Authors"},
+	}
+	content := "# Project\n\nIntro.\n\n### Configuration\n\nConfig.\n\n#### Nested\n\nNested.\n\n### Usage\n\nUse it.\n"
+	generated := "## Installation\n\nInstall it.\n\n## License\n\nMIT\n\n### Notes\n\nMore."
+
+	got, notFound := insertSections(content, generated, outline)
+	want := "# Project\n\nIntro.\n\n### Installation\n\nInstall it.\n\n### Configuration\n\nConfig.\n\n#### Nested\n\nNested.\n\n### Usage\n\nUse it.\n\n### License\n\nMIT\n\n#### Notes\n\nMore.\n"
+	if got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+
+	if !slices.Equal(sectionNames(notFound), []string{"Authors"}) {
+		t.Errorf("got: %v, want: %v", sectionNames(notFound), []string{"Authors"})
+	}
+}
+
+// TestLoadProjectConfig tests loading the outline from the project config.
+func TestLoadProjectConfig(t *testing.T) {
+	target := t.TempDir()
+
+	config, err := loadProjectConfig(target)
+	if err != nil || len(config.Sections) != 0 {
+		t.Fatalf("expected empty config without error, got %+v, %v", config, err)
+	}
+
+	path := filepath.Join(target, ProjectConfigName)
+	content := "sections:\n  - name: Installation\n    guidance: How to install\n    aliases: [Setup]\n  - name: Contributing\n    optional: true\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err = loadProjectConfig(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []OutlineSection{
+		{Name: "Installation", Guidance: "How to install", Aliases: []string{"Setup"}},
+		{Name: "Contributing", Optional: true},
+	}
+	if len(config.Sections) != len(want) || config.Sections[0].Guidance != want[0].Guidance || !slices.Equal(config.Sections[0].Aliases, want[0].Aliases) || !config.Sections[1].Optional {
+		t.Errorf("got: %+v, want: %+v", config.Sections, want)
+	}
+
+	if err := os.WriteFile(path, []byte("sections:\n  - guidance: missing name\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadProjectConfig(target); err == nil {
+		t.Error("expected error for section without a name")
+	}
+}
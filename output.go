@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var citationRegex = regexp.MustCompile(`【[^】]*】`)
+
+// CitationResolver resolves the file citation annotations added by the file_search
+// tool back to the paths of the original source files.
+type CitationResolver struct {
+	// Root is the target directory, used to make resolved paths relative
+	Root string
+	// Sources maps the name of each uploaded file to the source files it combines
+	Sources map[string]map[string]SourceFile
+	// Filename returns the name an uploaded file was uploaded with
+	Filename func(fileId string) (string, error)
+
+	filenames map[string]string
+}
+
+// Resolve returns the path of the source file that the given annotation refers to.
+// If the annotation contains a quote, the first source file containing the quote is used.
+// Otherwise the annotation is only resolved if the cited file contains a single source
+// file. false is returned if the citation cannot be resolved.
+func (resolver *CitationResolver) Resolve(annotation TextAnnotation) (string, bool) {
+	if resolver == nil || resolver.Filename == nil || len(annotation.FileCitation.FileId) == 0 {
+		return "", false
+	}
+
+	if resolver.filenames == nil {
+		resolver.filenames = map[string]string{}
+	}
+
+	fileId := annotation.FileCitation.FileId
+	filename, ok := resolver.filenames[fileId]
+	if !ok {
+		name, err := resolver.Filename(fileId)
+		if err != nil {
+			log.Debug(fmt.Sprintf("error resolving filename of cited file %s: %+v", fileId, err))
+		}
+		// cache failed lookups as well to avoid repeated requests
+		filename = name
+		resolver.filenames[fileId] = filename
+	}
+
+	files, ok := resolver.Sources[filename]
+	if !ok {
+		return "", false
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	// files are read one at a time, so that only a single source file is held in memory
+	path := ""
+	if quote := strings.TrimSpace(annotation.FileCitation.Quote); len(quote) > 0 {
+		for _, candidate := range paths {
+			if sourceFileContains(files[candidate], quote) {
+				path = candidate
+				break
+			}
+		}
+	} else if len(paths) == 1 {
+		path = paths[0]
+	}
+
+	if len(path) == 0 {
+		return "", false
+	}
+
+	if len(resolver.Root) > 0 {
+		if relative, err := filepath.Rel(resolver.Root, path); err == nil {
+			path = relative
+		}
+	}
+	return filepath.ToSlash(path), true
+}
+
+// sourceFileContains checks if the contents of the source file contain the quote.
+func sourceFileContains(file SourceFile, quote string) bool {
+	reader, err := file.Open()
+	if err != nil {
+		log.Debug(fmt.Sprintf("error opening cited file %s: %+v", file.Path, err))
+		return false
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		log.Debug(fmt.Sprintf("error reading cited file %s: %+v", file.Path, err))
+		return false
+	}
+	return strings.Contains(string(content), quote)
+}
+
+// extractAssistantOutput extracts the text generated by the assistant for the given
+// run from a list of thread messages.
+//
+// The latest assistant message created by the run is selected, and the values of all
+// of its text parts are concatenated. File citation annotations are replaced with the
+// path of the cited source file if the resolver can resolve them, and stripped
+// otherwise. Any remaining citation markers are removed from the text.
+//
+// Parameters:
+//   - messages: The messages of the thread the run was executed in.
+//   - threadId: The ID of the thread, used for error reporting.
+//   - runId: The ID of the run. If empty, assistant messages from any run are used.
+//   - resolver: The resolver used for file citations. May be nil to strip all citations.
+//
+// Returns:
+//   - string: The text generated by the assistant.
+//   - error: NoAssistantOutputError if the run did not create an assistant message,
+//     or EmptyAssistantOutputError if the message does not contain any text.
+func extractAssistantOutput(messages []ThreadMessageResponse, threadId, runId string, resolver *CitationResolver) (string, error) {
+	candidates := []ThreadMessageResponse{}
+	for _, message := range messages {
+		if len(runId) == 0 || message.RunId == runId {
+			candidates = append(candidates, message)
+		}
+	}
+
+	message, ok := latestAssistantMessage(candidates)
+	if !ok {
+		return "", NoAssistantOutputError{
+			ThreadId: threadId,
+			RunId:    runId,
+		}
+	}
+
+	parts := []string{}
+	for _, content := range message.Content {
+		if content.Type != "text" {
+			log.Debug(fmt.Sprintf("skipping %s content in message %s", content.Type, message.Id))
+			continue
+		}
+		parts = append(parts, replaceCitations(content.Text.Value, content.Text.Annotations, resolver))
+	}
+
+	output := strings.TrimSpace(strings.Join(parts, "\n\n"))
+	if len(output) == 0 {
+		return "", EmptyAssistantOutputError{
+			MessageId: message.Id,
+		}
+	}
+	return output, nil
+}
+
+// replaceCitations replaces the file citation annotations of a text value with
+// a reference to the resolved source file, and strips any citation that cannot be resolved.
+// annotations are replaced at the character offsets given by the API, so repeated citation
+// markers and markers quoted in the text are matched with the correct annotation.
+func replaceCitations(value string, annotations []TextAnnotation, resolver *CitationResolver) string {
+	// replace annotations from the end of the text so that the offsets
+	// of the remaining annotations are still valid
+	annotations = slices.Clone(annotations)
+	slices.SortFunc(annotations, func(a, b TextAnnotation) int {
+		return b.StartIndex - a.StartIndex
+	})
+
+	// offsets count characters rather than bytes
+	runes := []rune(value)
+	limit := len(runes)
+	for _, annotation := range annotations {
+		start, end := annotation.StartIndex, annotation.EndIndex
+		if start < 0 || start > end || end > limit || string(runes[start:end]) != annotation.Text {
+			log.Debug(fmt.Sprintf("skipping annotation %s with invalid offsets %d-%d", annotation.Text, start, end))
+			continue
+		}
+
+		replacement := ""
+		if annotation.Type == "file_citation" {
+			if path, ok := resolver.Resolve(annotation); ok {
+				replacement = fmt.Sprintf(" (`%s`)", path)
+			}
+		}
+
+		runes = slices.Concat(runes[:start], []rune(replacement), runes[end:])
+		// overlapping annotations are skipped
+		limit = start
+	}
+	value = string(runes)
+
+	return citationRegex.ReplaceAllString(value, "")
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// newTestMessage creates an assistant thread message with a single text part.
+func newTestMessage(id, runId string, createdAt int64, value string, annotations ...TextAnnotation) ThreadMessageResponse {
+	content := ThreadMessageContent{Type: "text"}
+	content.Text.Value = value
+	content.Text.Annotations = annotations
+
+	return ThreadMessageResponse{
+		Id:        id,
+		RunId:     runId,
+		Role:      "assistant",
+		CreatedAt: createdAt,
+		Content:   []ThreadMessageContent{content},
+	}
+}
+
+// newTestCitation creates a file citation annotation for the given file ID, at the
+// first occurrence of the citation text after the character offset from.
+func newTestCitation(value, text string, from int, fileId, quote string) TextAnnotation {
+	runes := []rune(value)
+	start := from + utf8.RuneCountInString(strings.SplitN(string(runes[from:]), text, 2)[0])
+	annotation := TextAnnotation{
+		Type:       "file_citation",
+		Text:       text,
+		StartIndex: start,
+		EndIndex:   start + utf8.RuneCountInString(text),
+	}
+	annotation.FileCitation.FileId = fileId
+	annotation.FileCitation.Quote = quote
+	return annotation
+}
+
+// newTestSourceFile creates a source file that is read from the given content.
+func newTestSourceFile(path, content string) SourceFile {
+	return SourceFile{
+		Path: path,
+		Size: int64(len(content)),
+		Open: func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+}
+
+// TestExtractAssistantOutput tests that extractAssistantOutput selects the assistant
+// message belonging to the run and concatenates all of its text parts.
+func TestExtractAssistantOutput(t *testing.T) {
+	message := newTestMessage("msg_2", "run_1", 20, "# Title")
+	second := ThreadMessageContent{Type: "text"}
+	second.Text.Value = "Body"
+	message.Content = append(message.Content, ThreadMessageContent{Type: "image_file"}, second)
+
+	messages := []ThreadMessageResponse{
+		newTestMessage("msg_3", "run_2", 30, "other run"),
+		message,
+		newTestMessage("msg_1", "run_1", 10, "older"),
+		{Id: "msg_0", Role: "user", CreatedAt: 40},
+	}
+
+	output, err := extractAssistantOutput(messages, "thread_1", "run_1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if output != "# Title\n\nBody" {
+		t.Errorf("got: %q, want: %q", output, "# Title\n\nBody")
+	}
+}
+
+// TestExtractAssistantOutputErrors tests that extractAssistantOutput returns typed
+// errors when the run did not produce any output.
+func TestExtractAssistantOutputErrors(t *testing.T) {
+	_, err := extractAssistantOutput([]ThreadMessageResponse{}, "thread_1", "run_1", nil)
+	var noOutput NoAssistantOutputError
+	if !errors.As(err, &noOutput) {
+		t.Fatalf("expected NoAssistantOutputError, got %+v", err)
+	}
+
+	messages := []ThreadMessageResponse{
+		newTestMessage("msg_1", "run_1", 10, " 【4:0†source】 "),
+	}
+	_, err = extractAssistantOutput(messages, "thread_1", "run_1", nil)
+	var emptyOutput EmptyAssistantOutputError
+	if !errors.As(err, &emptyOutput) {
+		t.Fatalf("expected EmptyAssistantOutputError, got %+v", err)
+	}
+}
+
+// TestExtractAssistantOutputCitations tests that file citations are resolved to the
+// original source paths using the quoted content, and stripped if they cannot be resolved.
+func TestExtractAssistantOutputCitations(t *testing.T) {
+	resolver := &CitationResolver{
+		Root: "project",
+		Sources: map[string]map[string]SourceFile{
+			"combined_source_files.py": {
+				"project/src/a.py": newTestSourceFile("project/src/a.py", "def foo():\n    pass\n"),
+				"project/src/b.py": newTestSourceFile("project/src/b.py", "def bar():\n    pass\n"),
+			},
+			"combined_source_files.go": {
+				"project/main.go": newTestSourceFile("project/main.go", "package main\n"),
+			},
+		},
+		Filename: func(fileId string) (string, error) {
+			switch fileId {
+			case "file_py":
+				return "combined_source_files.py", nil
+			case "file_go":
+				return "combined_source_files.go", nil
+			default:
+				return "", errors.New("file not found")
+			}
+		},
+	}
+
+	value := "Bar is defined【4:0†source】. Entry point【4:1†source】. Unknown【4:2†source】. Stray【9:9†source】."
+	messages := []ThreadMessageResponse{
+		newTestMessage("msg_1", "run_1", 10, value,
+			newTestCitation(value, "【4:0†source】", 0, "file_py", "def bar()"),
+			newTestCitation(value, "【4:1†source】", 0, "file_go", ""),
+			newTestCitation(value, "【4:2†source】", 0, "file_missing", ""),
+		),
+	}
+
+	output, err := extractAssistantOutput(messages, "thread_1", "run_1", resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Bar is defined (`src/b.py`). Entry point (`main.go`). Unknown. Stray."
+	if output != expected {
+		t.Errorf("got: %q, want: %q", output, expected)
+	}
+
+	// repeated markers are replaced at the offsets of their annotations, and markers
+	// that are part of the text are not mistaken for the annotation
+	value = "Foo【4:0†source】 and bar【4:0†source】. Unrelated【4:0†source】."
+	first := newTestCitation(value, "【4:0†source】", 0, "file_go", "")
+	second := newTestCitation(value, "【4:0†source】", first.EndIndex, "file_py", "def bar()")
+	messages = []ThreadMessageResponse{newTestMessage("msg_2", "run_2", 20, value, second, first)}
+
+	output, err = extractAssistantOutput(messages, "thread_1", "run_2", resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "Foo (`main.go`) and bar (`src/b.py`). Unrelated."
+	if output != expected {
+		t.Errorf("got: %q, want: %q", output, expected)
+	}
+}
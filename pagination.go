@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// ListIterator walks over every item of a cursor paginated ChatGPT list
+// endpoint. pages are only fetched when the items of the previous page
+// have been consumed, so callers can stop early without requesting the
+// remaining pages.
+type ListIterator[T any] struct {
+	fetch   func(opts ListOptions) (ListResponse[T], error)
+	opts    ListOptions
+	page    []T
+	index   int
+	current T
+	hasMore bool
+	started bool
+	err     error
+}
+
+// NewListIterator creates a new iterator starting at the position described
+// by the provided list options. fetch is called once for every page, with the
+// cursor of the options moved forward after each page.
+func NewListIterator[T any](opts ListOptions, fetch func(opts ListOptions) (ListResponse[T], error)) *ListIterator[T] {
+	return &ListIterator[T]{
+		fetch: fetch,
+		opts:  opts,
+	}
+}
+
+// Next advances the iterator to the next item, fetching a new page if
+// required. It returns false once all items have been read or an error
+// was encountered, in which case Err returns the error.
+func (it *ListIterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.index >= len(it.page) {
+		if it.started && !it.hasMore {
+			return false
+		}
+
+		response, err := it.fetch(it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started = true
+		it.page = response.Data
+		it.index = 0
+		it.hasMore = response.HasMore && len(response.Data) > 0
+
+		// move cursor in the direction the list is being read
+		if len(it.opts.Before) > 0 {
+			it.opts.Before = response.FirstId
+		} else {
+			it.opts.After = response.LastId
+		}
+	}
+
+	it.current = it.page[it.index]
+	it.index++
+	return true
+}
+
+// Value returns the item the iterator is currently positioned at.
+func (it *ListIterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the first error encountered while fetching pages.
+func (it *ListIterator[T]) Err() error {
+	return it.err
+}
+
+// All consumes the remaining items of the iterator and returns them
+// as a single slice.
+func (it *ListIterator[T]) All() ([]T, error) {
+	items := []T{}
+	for it.Next() {
+		items = append(items, it.Value())
+	}
+	return items, it.Err()
+}
+
+// Query converts the list options into the query parameters
+// expected by the ChatGPT list endpoints. empty options are omitted
+// so the API defaults are used.
+func (opts ListOptions) Query() url.Values {
+	query := url.Values{}
+	if len(opts.After) > 0 {
+		query.Set("after", opts.After)
+	}
+	if len(opts.Before) > 0 {
+		query.Set("before", opts.Before)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if len(opts.Order) > 0 {
+		query.Set("order", opts.Order)
+	}
+	return query
+}
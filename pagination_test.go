@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestListIteratorPages tests that the ListIterator reads every page of a paginated
+// list, moving the after cursor to the last ID of each page until has_more is false.
+func TestListIteratorPages(t *testing.T) {
+	pages := map[string]ListResponse[string]{
+		"":  {Data: []string{"a", "b"}, FirstId: "a", LastId: "b", HasMore: true},
+		"b": {Data: []string{"c", "d"}, FirstId: "c", LastId: "d", HasMore: true},
+		"d": {Data: []string{"e"}, FirstId: "e", LastId: "e", HasMore: false},
+	}
+
+	cursors := []string{}
+	iterator := NewListIterator(ListOptions{Limit: 2}, func(opts ListOptions) (ListResponse[string], error) {
+		cursors = append(cursors, opts.After)
+		return pages[opts.After], nil
+	})
+
+	items, err := iterator.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(items, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("got: %v, want: %v", items, []string{"a", "b", "c", "d", "e"})
+	}
+
+	if !slices.Equal(cursors, []string{"", "b", "d"}) {
+		t.Errorf("got: %v, want: %v", cursors, []string{"", "b", "d"})
+	}
+}
+
+// TestListIteratorBefore tests that the ListIterator moves the before cursor
+// to the first ID of each page when reading a list backwards.
+func TestListIteratorBefore(t *testing.T) {
+	pages := map[string]ListResponse[string]{
+		"z": {Data: []string{"y", "x"}, FirstId: "x", LastId: "y", HasMore: true},
+		"x": {Data: []string{"w"}, FirstId: "w", LastId: "w", HasMore: false},
+	}
+
+	iterator := NewListIterator(ListOptions{Before: "z"}, func(opts ListOptions) (ListResponse[string], error) {
+		if len(opts.After) > 0 {
+			t.Fatalf("unexpected after cursor %s", opts.After)
+		}
+		return pages[opts.Before], nil
+	})
+
+	items, err := iterator.All()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(items, []string{"y", "x", "w"}) {
+		t.Errorf("got: %v, want: %v", items, []string{"y", "x", "w"})
+	}
+}
+
+// TestListIteratorError tests that the ListIterator stops and reports the
+// error returned while fetching a page.
+func TestListIteratorError(t *testing.T) {
+	fetchErr := errors.New("test error")
+	iterator := NewListIterator(ListOptions{}, func(opts ListOptions) (ListResponse[int], error) {
+		if len(opts.After) > 0 {
+			return ListResponse[int]{}, fetchErr
+		}
+		return ListResponse[int]{Data: []int{1}, LastId: "1", HasMore: true}, nil
+	})
+
+	items, err := iterator.All()
+	if !errors.Is(err, fetchErr) {
+		t.Fatalf("got: %v, want: %v", err, fetchErr)
+	}
+
+	if len(items) != 1 {
+		t.Errorf("got: %d, want: %d", len(items), 1)
+	}
+}
+
+// TestListOptionsQuery tests that only the provided list options are
+// converted into query parameters.
+func TestListOptionsQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ListOptions
+		want string
+	}{
+		{
+			name: "empty options",
+			opts: ListOptions{},
+			want: "",
+		},
+		{
+			name: "all options",
+			opts: ListOptions{After: "a", Before: "b", Limit: 20, Order: "asc"},
+			want: "after=a&before=b&limit=20&order=asc",
+		},
+		{
+			name: "limit and order",
+			opts: ListOptions{Limit: 100, Order: "desc"},
+			want: "limit=100&order=desc",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.opts.Query().Encode()
+			if got != test.want {
+				t.Errorf("got: %s, want: %s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	fenceRegex    = regexp.MustCompile("^\\s{0,3}(`{3,}|~{3,})\\s*([\\w+-]*)")
+	headingRegex  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	preambleRegex = regexp.MustCompile(`(?i)^(sure|certainly|of course|absolutely|okay|ok|great)\b|^(here('s| is| are)|below is|below you will find|the following is)\b`)
+	offerRegex    = regexp.MustCompile(`(?i)^((please )?let me know|would you like|if you need any (further|other) (changes|adjustments|modifications|help)|(is there |if there('s| is) )?anything else|(i )?hope this helps)\b`)
+	sentenceRegex = regexp.MustCompile(`.+?(?:[.!?]+(?:\s+|$)|$)`)
+)
+
+// PostProcessor is a single stage of the pipeline used to clean up the README
+// content generated by the assistant before it is written to disk.
+type PostProcessor func(content string) string
+
+// defaultPostProcessors are the stages applied to all generated READMEs, in order.
+var defaultPostProcessors = []PostProcessor{
+	unwrapMarkdownFence,
+	trimPreamble,
+	trimTrailingOffers,
+	normalizeHeadingLevels,
+	removeCombinedFileReferences,
+}
+
+// postProcessOutput runs the provided content through each of the post processors
+// in order, and returns the result terminated by a single newline.
+func postProcessOutput(content string, processors ...PostProcessor) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	for _, process := range processors {
+		content = process(content)
+	}
+	return strings.TrimSpace(content) + "\n"
+}
+
+// parseFence checks if a line opens or closes a fenced code block, returning
+// the fence marker (e.g. ```) and the info string following the marker.
+func parseFence(line string) (string, string, bool) {
+	match := fenceRegex.FindStringSubmatch(line)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// fenceState tracks whether the lines of a markdown document are part of a fenced code block.
+type fenceState struct {
+	marker string
+}
+
+// Update processes the next line of the document, and returns true if the line
+// opens or closes a fenced code block.
+func (state *fenceState) Update(line string) bool {
+	marker, _, ok := parseFence(line)
+	if !ok {
+		return false
+	}
+
+	if len(state.marker) == 0 {
+		state.marker = marker
+	} else if strings.HasPrefix(marker, state.marker[:1]) && len(marker) >= len(state.marker) {
+		state.marker = ""
+	} else {
+		// fences of a different type are part of the open code block
+		return false
+	}
+	return true
+}
+
+// Open checks if the last processed line is inside a fenced code block.
+func (state *fenceState) Open() bool {
+	return len(state.marker) > 0
+}
+
+// forEachProseLine calls fn for every line that is not part of a fenced code block.
+// fn returns the replacement for the line, and false if the line should be removed.
+func forEachProseLine(content string, fn func(line string) (string, bool)) string {
+	lines := strings.Split(content, "\n")
+	output := make([]string, 0, len(lines))
+
+	var fence fenceState
+	for _, line := range lines {
+		if fence.Update(line) || fence.Open() {
+			output = append(output, line)
+			continue
+		}
+
+		if replaced, keep := fn(line); keep {
+			output = append(output, replaced)
+		}
+	}
+	return strings.Join(output, "\n")
+}
+
+// unwrapMarkdownFence removes the fenced code block that models often wrap the
+// whole README in. The content is only unwrapped if, ignoring conversational lines
+// before and after it, the document starts with a markdown (or untagged) fence and
+// ends with the fence closing it, no other fence closes it in between, and the
+// wrapped content contains a heading.
+func unwrapMarkdownFence(content string) string {
+	lines := strings.Split(content, "\n")
+
+	// conversational lines around the fence are removed along with the fence
+	first, last := 0, len(lines)-1
+	for first < len(lines) && isBlankOr(lines[first], preambleRegex) {
+		first++
+	}
+	for last > first && isBlankOr(lines[last], offerRegex) {
+		last--
+	}
+	if last <= first {
+		return content
+	}
+
+	marker, info, ok := parseFence(lines[first])
+	if !ok {
+		return content
+	}
+	switch strings.ToLower(info) {
+	case "markdown", "md", "":
+	default:
+		return content
+	}
+
+	if closing, closingInfo, ok := parseFence(lines[last]); !ok || !closesFence(marker, closing, closingInfo) {
+		return content
+	}
+
+	// code blocks inside the wrapped content must be closed before the wrapping fence
+	// is, otherwise the first and last fences belong to separate code blocks
+	inner := lines[first+1 : last]
+	nested := ""
+	for _, line := range inner {
+		fence, fenceInfo, ok := parseFence(line)
+		switch {
+		case !ok:
+		case len(nested) > 0:
+			if closesFence(nested, fence, fenceInfo) {
+				nested = ""
+			}
+		case closesFence(marker, fence, fenceInfo):
+			return content
+		default:
+			nested = fence
+		}
+	}
+	if len(nested) > 0 {
+		return content
+	}
+
+	for _, line := range inner {
+		if headingRegex.MatchString(line) {
+			return strings.Join(inner, "\n")
+		}
+	}
+	return content
+}
+
+// closesFence checks if a fence closes the code block opened by the marker. closing
+// fences use the same character as the opening fence, are at least as long, and have
+// no info string.
+func closesFence(marker, fence, info string) bool {
+	return len(info) == 0 && fence[:1] == marker[:1] && len(fence) >= len(marker)
+}
+
+// isBlankOr checks if a line is blank or matches the expression once trimmed.
+func isBlankOr(line string, expression *regexp.Regexp) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) == 0 || expression.MatchString(trimmed)
+}
+
+// trimPreamble removes conversational lines such as "Here is your README:"
+// that appear before the first heading of the document. documents without
+// any headings are returned unchanged.
+func trimPreamble(content string) string {
+	lines := strings.Split(content, "\n")
+
+	start := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if len(trimmed) == 0 {
+			continue
+		}
+		if headingRegex.MatchString(trimmed) {
+			return strings.Join(lines[start:], "\n")
+		}
+		if !preambleRegex.MatchString(trimmed) {
+			break
+		}
+		start = i + 1
+	}
+	return content
+}
+
+// trimTrailingOffers removes closing paragraphs addressed to the requester such as
+// "Let me know if you need anything else", along with any horizontal rule left before
+// them. the only paragraph under the last heading is always kept, as it is part of a
+// section of the README rather than a message to the requester.
+func trimTrailingOffers(content string) string {
+	paragraphs := strings.Split(strings.TrimSpace(content), "\n\n")
+
+	for len(paragraphs) > 1 {
+		last := strings.TrimSpace(paragraphs[len(paragraphs)-1])
+		if isRule(last) || (offerRegex.MatchString(last) && !followsHeading(paragraphs[:len(paragraphs)-1])) {
+			paragraphs = paragraphs[:len(paragraphs)-1]
+			continue
+		}
+		break
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// isRule checks if a paragraph is empty or a horizontal rule.
+func isRule(paragraph string) bool {
+	return len(paragraph) == 0 || paragraph == "---" || paragraph == "***"
+}
+
+// followsHeading checks if the last paragraph, ignoring horizontal rules, ends with a heading.
+func followsHeading(paragraphs []string) bool {
+	for i := len(paragraphs) - 1; i >= 0; i-- {
+		paragraph := strings.TrimSpace(paragraphs[i])
+		if isRule(paragraph) {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		return headingRegex.MatchString(strings.TrimSpace(lines[len(lines)-1]))
+	}
+	return false
+}
+
+// normalizeHeadingLevels shifts all headings so that the highest level heading
+// in the document is a level one heading.
+func normalizeHeadingLevels(content string) string {
+	minimum := 7
+	forEachProseLine(content, func(line string) (string, bool) {
+		if match := headingRegex.FindStringSubmatch(line); match != nil {
+			minimum = min(minimum, len(match[1]))
+		}
+		return line, true
+	})
+
+	if minimum == 7 || minimum == 1 {
+		return content
+	}
+
+	return forEachProseLine(content, func(line string) (string, bool) {
+		match := headingRegex.FindStringSubmatch(line)
+		if match == nil {
+			return line, true
+		}
+		return strings.Repeat("#", len(match[1])-minimum+1) + " " + match[2], true
+	})
+}
+
+// removeCombinedFileReferences removes references to the combined_source_files
+// uploads. list items, headings and table rows that mention the combined files
+// are removed entirely, while only the offending sentences are removed from prose.
+func removeCombinedFileReferences(content string) string {
+	return forEachProseLine(content, func(line string) (string, bool) {
+		if !strings.Contains(line, "combined_source_files") {
+			return line, true
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if headingRegex.MatchString(trimmed) || strings.HasPrefix(trimmed, "|") || isListItem(trimmed) {
+			return "", false
+		}
+
+		kept := []string{}
+		for _, sentence := range sentenceRegex.FindAllString(line, -1) {
+			if !strings.Contains(sentence, "combined_source_files") {
+				kept = append(kept, sentence)
+			}
+		}
+
+		replaced := strings.TrimRight(strings.Join(kept, ""), " ")
+		return replaced, len(strings.TrimSpace(replaced)) > 0
+	})
+}
+
+// isListItem checks if a trimmed line is an ordered or unordered markdown list item.
+func isListItem(line string) bool {
+	if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ") {
+		return true
+	}
+
+	digits := strings.TrimLeft(line, "0123456789")
+	return len(digits) < len(line) && (strings.HasPrefix(digits, ". ") || strings.HasPrefix(digits, ") "))
+}
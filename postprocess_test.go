@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestPostProcessOutput tests the full post processing pipeline against
+// typical assistant output, checking that fences, preambles, trailing offers
+// and references to the combined files are removed, and headings are normalized.
+func TestPostProcessOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "clean output",
+			content: "# Title\n\nSome text.",
+			want:    "# Title\n\nSome text.\n",
+		},
+		{
+			name:    "markdown fence with preamble",
+			content: "Here is your README:\n\n```markdown\n# Title\n\n```bash\ngo build\n```\n```\n\nLet me know if you need changes!",
+			want:    "# Title\n\n```bash\ngo build\n```\n",
+		},
+		{
+			name:    "untagged fence",
+			content: "```\n## Title\n\nText\n```",
+			want:    "# Title\n\nText\n",
+		},
+		{
+			name:    "longer fence wrapping code blocks",
+			content: "````markdown\n# Title\n\n```\ngo build\n```\n````",
+			want:    "# Title\n\n```\ngo build\n```\n",
+		},
+		{
+			name:    "first and last code blocks are not unwrapped",
+			content: "```\n# install\nmake\n```\n\n# Title\n\nText\n\n```bash\ngo build\n```",
+			want:    "```\n# install\nmake\n```\n\n# Title\n\nText\n\n```bash\ngo build\n```\n",
+		},
+		{
+			name:    "prose around code blocks is kept",
+			content: "# Title\n\nInstall using\n\n```\nmake\n```\n\n## Usage\n\n```\ngo run .\n```\n\nDone.",
+			want:    "# Title\n\nInstall using\n\n```\nmake\n```\n\n## Usage\n\n```\ngo run .\n```\n\nDone.\n",
+		},
+		{
+			name:    "code block only is not unwrapped",
+			content: "```bash\ngo build\n```",
+			want:    "```bash\ngo build\n```\n",
+		},
+		{
+			name:    "preamble and trailing offer",
+			content: "Sure! Below is the README.\n\n# Title\n\nText\n\n---\n\nIf you need any further changes, feel free to ask.",
+			want:    "# Title\n\nText\n",
+		},
+		{
+			name:    "contributing section is kept",
+			content: "# Project\n\n## Contributing\n\nFeel free to open an issue or submit a pull request.\n",
+			want:    "# Project\n\n## Contributing\n\nFeel free to open an issue or submit a pull request.\n",
+		},
+		{
+			name:    "support section is kept",
+			content: "## Support\n\nIf you have questions, open a GitHub discussion.",
+			want:    "# Support\n\nIf you have questions, open a GitHub discussion.\n",
+		},
+		{
+			name:    "offer after a section is removed",
+			content: "# Project\n\n## Support\n\nIf you have questions, open a GitHub discussion.\n\nLet me know if you would like a FAQ section added.",
+			want:    "# Project\n\n## Support\n\nIf you have questions, open a GitHub discussion.\n",
+		},
+		{
+			name:    "only paragraph under the last heading is kept",
+			content: "# Project\n\n## Feedback\n\nLet me know what you think by opening an issue.",
+			want:    "# Project\n\n## Feedback\n\nLet me know what you think by opening an issue.\n",
+		},
+		{
+			name:    "preamble without heading is kept",
+			content: "Here is a tool for generating docs.",
+			want:    "Here is a tool for generating docs.\n",
+		},
+		{
+			name:    "heading levels shifted",
+			content: "## Title\n\n### Usage\n\n```bash\n# comment\n```",
+			want:    "# Title\n\n## Usage\n\n```bash\n# comment\n```\n",
+		},
+		{
+			name:    "combined file references removed",
+			content: "# Title\n\nThe code lives in combined_source_files.py for now. It prints text.\n\n- combined_source_files.go\n- main.go\n\n```\ncombined_source_files\n```",
+			want:    "# Title\n\nIt prints text.\n\n- main.go\n\n```\ncombined_source_files\n```\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := postProcessOutput(test.content, defaultPostProcessors...)
+			if got != test.want {
+				t.Errorf("got: %q, want: %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestIsListItem tests that isListItem identifies ordered and unordered list items.
+func TestIsListItem(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "- item", want: true},
+		{line: "* item", want: true},
+		{line: "12. item", want: true},
+		{line: "1) item", want: true},
+		{line: "2024 was a year", want: false},
+		{line: "text", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.line, func(t *testing.T) {
+			got := isListItem(test.line)
+			if got != test.want {
+				t.Errorf("got: %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
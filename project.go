@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+const ProjectConfigName = ".goreadme.yaml"
+
+// ProjectConfig holds the settings of a single project, loaded from the
+// .goreadme.yaml file in the root of the target directory.
+type ProjectConfig struct {
+	// Include contains patterns of files to upload. all discovered files are uploaded if empty
+	Include []string `yaml:"include"`
+	// Exclude contains patterns of files that are never uploaded
+	Exclude []string `yaml:"exclude"`
+	// Output is the path the README is written to, relative to the target directory
+	Output string `yaml:"output"`
+	// PromptTemplate is the path of the prompt template, relative to the target directory
+	PromptTemplate string `yaml:"promptTemplate"`
+	// Sections is the outline of the sections the generated README must contain
+	Sections []OutlineSection `yaml:"sections"`
+	// Model overrides the model of the global config
+	Model string `yaml:"model"`
+	// MaxFileSize is the maximum size of a single uploaded file, e.g. 512KB
+	MaxFileSize ByteSize `yaml:"maxFileSize"`
+	// MaxTotalSize is the maximum size of all uploaded files, e.g. 5MB
+	MaxTotalSize ByteSize `yaml:"maxTotalSize"`
+	// Concurrency is the number of files uploaded or deleted at the same time
+	Concurrency int `yaml:"concurrency"`
+	// FollowSymlinks follows symlinks when discovering files
+	FollowSymlinks bool `yaml:"followSymlinks"`
+	// SameFilesystem skips paths on a different filesystem to the target directory
+	SameFilesystem bool `yaml:"sameFilesystem"`
+	// Secrets is the secrets mode, either redact, abort or off
+	Secrets string `yaml:"secrets"`
+	// SecretPatterns are custom secret patterns, used in addition to the built in rules
+	SecretPatterns []SecretPattern `yaml:"secretPatterns"`
+}
+
+// ByteSize is a size in bytes that is decoded from YAML using parseSize, so
+// sizes can be written with a KB, MB or GB suffix.
+type ByteSize int64
+
+func (size *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := parseSize(node.Value)
+	if err != nil {
+		return fmt.Errorf("invalid size %s on line %d: %w", node.Value, node.Line, err)
+	}
+	*size = ByteSize(parsed)
+	return nil
+}
+
+// loadProjectConfig loads the project config from the target directory. An empty
+// config is returned if the project does not contain a config file.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//
+// Returns:
+//   - ProjectConfig: The loaded project config.
+//   - error: An error if the config file exists but cannot be read, is invalid YAML,
+//     contains an invalid size, contains sections without a name, has a negative concurrency,
+//     has an invalid secrets mode or contains an invalid secret pattern.
+func loadProjectConfig(target string) (ProjectConfig, error) {
+	var config ProjectConfig
+
+	path := filepath.Join(target, ProjectConfigName)
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return config, err
+	}
+
+	if err := yaml.Unmarshal(contents, &config); err != nil {
+		return config, fmt.Errorf("error decoding project config %s: %w", path, err)
+	}
+
+	for i, section := range config.Sections {
+		if len(section.Name) == 0 {
+			return config, fmt.Errorf("section %d of project config %s does not have a name", i+1, path)
+		}
+	}
+	if config.Concurrency < 0 {
+		return config, fmt.Errorf("concurrency of project config %s must be at least 1", path)
+	}
+	if len(config.Secrets) > 0 {
+		mode, err := parseSecretsMode(config.Secrets)
+		if err != nil {
+			return config, fmt.Errorf("invalid secrets mode %s of project config %s: %w", config.Secrets, path, err)
+		}
+		config.Secrets = mode
+	}
+	if _, err := newSecretRules(config.SecretPatterns); err != nil {
+		return config, fmt.Errorf("error loading project config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// addProjectExcludes adds exclude patterns to the project config of the target directory,
+// creating the config file if it does not exist. The file is edited as a YAML document, so
+// the other settings and comments of the file are kept. patterns that are already excluded
+// are not added again.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - patterns: The exclude patterns to add.
+//
+// Returns:
+//   - error: An error if the config file cannot be read, decoded or written.
+func addProjectExcludes(target string, patterns []string) error {
+	path := filepath.Join(target, ProjectConfigName)
+	contents, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return fmt.Errorf("error decoding project config %s: %w", path, err)
+	}
+	if document.Kind == 0 {
+		document = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("project config %s is not a mapping", path)
+	}
+
+	var exclude *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "exclude" {
+			exclude = root.Content[i+1]
+		}
+	}
+	if exclude == nil {
+		exclude = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "exclude"}, exclude)
+	}
+	if exclude.Kind != yaml.SequenceNode {
+		return fmt.Errorf("exclude of project config %s is not a list", path)
+	}
+
+	for _, pattern := range patterns {
+		excluded := slices.ContainsFunc(exclude.Content, func(node *yaml.Node) bool {
+			return node.Value == pattern
+		})
+		if !excluded {
+			exclude.Content = append(exclude.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: pattern})
+		}
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buffer.Bytes(), 0644)
+}
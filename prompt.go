@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+const (
+	PromptTemplateName = "prompt.tmpl"
+
+	DefaultPromptTemplate = `Please generate a README for the attached source code. All of the files for a
+given file extension have been combined into a single file called combined_source_files.[ext]
+where ext is the file extension. The combined file is organized into a set of file blocks,
+where each block starts with
+
+### FILE START [filepath]
+
+and ends with
+
+### FILE END [filepath]
+
+where [filepath] gives the path of the original source code file. Treat the code within
+each file block as a separate file for the purposes of the README. Some files that have extensions
+that are not supported for ChatGPT retrieval (such as .vue files) are combined into a .[ext].txt file.
+
+Please do not include any references to the combined_source_files.[ext] file containing the
+combined source code. Only reference the original source code files using the file names provided.
+Ensure that context is provided that explains the purpose of the code and how it can be used
+where possible.
+{{- if .ProjectName}}
+
+The project is called {{.ProjectName}}.
+{{- end}}
+{{- if .Languages}}
+
+The project is written in {{join .Languages ", "}}.
+{{- end}}
+{{- if .Files}}
+
+The project contains the following source files:
+{{range .Files}}
+- {{.}}
+{{- end}}
+{{- end}}
+{{- if .Outline}}
+
+The README must contain the following sections, in this order:
+{{range .Outline}}
+- {{.Name}}{{if .Optional}} (optional){{end}}{{if .Guidance}}: {{.Guidance}}{{end}}
+{{- end}}
+{{- end}}
+{{- if .Audience}}
+
+The README is written for the following audience: {{.Audience}}.
+{{- end}}
+{{- if .Tone}}
+
+Use the following tone: {{.Tone}}.
+{{- end}}
+{{- if .ExistingReadme}}
+
+The project already has the following README. Keep any information from it that is still
+accurate, and update anything that no longer matches the source code.
+
+{{.ExistingReadme}}
+{{- end}}
+`
+)
+
+// languageNames maps file extensions to the name of the language they contain.
+var languageNames = map[string]string{
+	".c":    "C",
+	".cpp":  "C++",
+	".css":  "CSS",
+	".go":   "Go",
+	".html": "HTML",
+	".java": "Java",
+	".js":   "JavaScript",
+	".jsx":  "JavaScript",
+	".php":  "PHP",
+	".py":   "Python",
+	".rb":   "Ruby",
+	".tex":  "LaTeX",
+	".ts":   "TypeScript",
+	".tsx":  "TypeScript",
+	".vue":  "Vue",
+	".sh":   "Shell",
+	".bash": "Shell",
+	".zsh":  "Shell",
+	".ps1":  "PowerShell",
+}
+
+// PromptData holds the variables available to prompt templates.
+type PromptData struct {
+	ProjectName    string
+	Languages      []string
+	Files          []string
+	ExistingReadme string
+	// Sections contains the names of the sections of the outline
+	Sections []string
+	Outline  []OutlineSection
+	Audience string
+	Tone     string
+}
+
+// newPromptData creates the template variables for the project in the target directory.
+// languages are detected using the extensions of the discovered files, and the existing
+// README is read from the target directory if present.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - files: The discovered files of the project, relative to the target directory.
+//
+// Returns:
+//   - PromptData: The template variables. The outline, audience and tone are left empty.
+func newPromptData(target string, files []string) PromptData {
+	data := PromptData{
+		ProjectName: filepath.Base(target),
+		Languages:   []string{},
+		Files:       files,
+	}
+	if absolute, err := filepath.Abs(target); err == nil {
+		data.ProjectName = filepath.Base(absolute)
+	}
+
+	for _, file := range files {
+		// renamed files such as .vue.txt use the extension of the original file
+		ext := filepath.Ext(strings.TrimSuffix(file, ".txt"))
+		if language, ok := languageNames[ext]; ok && !slices.Contains(data.Languages, language) {
+			data.Languages = append(data.Languages, language)
+		}
+	}
+	slices.Sort(data.Languages)
+
+	if readme, err := os.ReadFile(filepath.Join(target, "README.md")); err == nil {
+		data.ExistingReadme = strings.TrimSpace(string(readme))
+	}
+	return data
+}
+
+// promptTemplatePaths returns the locations that prompt templates are loaded from,
+// in order of precedence: the .goreadme directory of the project, followed by the
+// directory containing the goreadme config file.
+func promptTemplatePaths(target, configDir string) []string {
+	return []string{
+		filepath.Join(target, ".goreadme", PromptTemplateName),
+		filepath.Join(configDir, PromptTemplateName),
+	}
+}
+
+// loadPromptTemplate loads the prompt template with the highest precedence. If no
+// template file exists, the built in DefaultPromptTemplate is used.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - configDir: The directory containing the goreadme config file.
+//
+// Returns:
+//   - string: The contents of the template.
+//   - string: The path the template was loaded from, or "built-in" for the default template.
+//   - error: An error if a template file exists but cannot be read.
+func loadPromptTemplate(target, configDir string) (string, string, error) {
+	for _, path := range promptTemplatePaths(target, configDir) {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return "", path, err
+		}
+		return string(content), path, nil
+	}
+	return DefaultPromptTemplate, "built-in", nil
+}
+
+// renderPrompt executes a prompt template using the provided variables. The join
+// function (strings.Join) is available to templates in addition to the text/template builtins.
+func renderPrompt(text string, data PromptData) (string, error) {
+	tmpl, err := template.New(PromptTemplateName).Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("error parsing prompt template: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, data); err != nil {
+		return "", fmt.Errorf("error rendering prompt template: %w", err)
+	}
+	return strings.TrimSpace(buffer.String()), nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestNewPromptData tests that newPromptData detects the project name and
+// languages from the discovered files.
+func TestNewPromptData(t *testing.T) {
+	data := newPromptData("tests/src", []string{"main.py", "nested/example.py", "app.vue.txt", "run.sh"})
+
+	if data.ProjectName != "src" {
+		t.Errorf("got: %s, want: %s", data.ProjectName, "src")
+	}
+
+	if !slices.Equal(data.Languages, []string{"Python", "Shell", "Vue"}) {
+		t.Errorf("got: %v, want: %v", data.Languages, []string{"Python", "Shell", "Vue"})
+	}
+
+	if len(data.ExistingReadme) > 0 {
+		t.Errorf("expected no existing README, got %s", data.ExistingReadme)
+	}
+}
+
+// TestRenderDefaultPrompt tests that the default prompt template only includes
+// the optional instructions for the variables that are set.
+func TestRenderDefaultPrompt(t *testing.T) {
+	prompt, err := renderPrompt(DefaultPromptTemplate, PromptData{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(prompt, "Please generate a README") || !strings.HasSuffix(prompt, "where possible.") {
+		t.Errorf("unexpected prompt for empty data: %s", prompt)
+	}
+
+	prompt, err = renderPrompt(DefaultPromptTemplate, PromptData{
+		ProjectName: "goreadme",
+		Languages:   []string{"Go", "Python"},
+		Outline: []OutlineSection{
+			{Name: "Installation", Guidance: "how to install the CLI"},
+			{Name: "Usage", Optional: true},
+		},
+		Audience:       "developers",
+		ExistingReadme: "# Old README",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"The project is called goreadme.",
+		"The project is written in Go, Python.",
+		"- Installation: how to install the CLI\n- Usage (optional)",
+		"following audience: developers.",
+		"# Old README",
+	} {
+		if !strings.Contains(prompt, expected) {
+			t.Errorf("expected prompt to contain %q, got %s", expected, prompt)
+		}
+	}
+
+	if strings.Contains(prompt, "tone") {
+		t.Errorf("expected prompt without tone, got %s", prompt)
+	}
+}
+
+// TestLoadPromptTemplate tests that templates are loaded from the project before
+// the config directory, falling back to the built in template.
+func TestLoadPromptTemplate(t *testing.T) {
+	target := t.TempDir()
+	configDir := t.TempDir()
+
+	text, source, err := loadPromptTemplate(target, configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != "built-in" || text != DefaultPromptTemplate {
+		t.Errorf("got: %s, want: %s", source, "built-in")
+	}
+
+	userTemplate := filepath.Join(configDir, PromptTemplateName)
+	if err := os.WriteFile(userTemplate, []byte("user {{.ProjectName}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, source, _ := loadPromptTemplate(target, configDir); source != userTemplate {
+		t.Errorf("got: %s, want: %s", source, userTemplate)
+	}
+
+	projectTemplate := filepath.Join(target, ".goreadme", PromptTemplateName)
+	if err := os.MkdirAll(filepath.Dir(projectTemplate), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(projectTemplate, []byte("project {{join .Languages \"/\"}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	text, source, err = loadPromptTemplate(target, configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != projectTemplate {
+		t.Errorf("got: %s, want: %s", source, projectTemplate)
+	}
+
+	prompt, err := renderPrompt(text, PromptData{Languages: []string{"Go", "C"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prompt != "project Go/C" {
+		t.Errorf("got: %s, want: %s", prompt, "project Go/C")
+	}
+}
+
+// TestRenderPromptError tests that renderPrompt returns an error for templates
+// that reference unknown variables.
+func TestRenderPromptError(t *testing.T) {
+	if _, err := renderPrompt("{{.Unknown}}", PromptData{}); err == nil {
+		t.Fatal("expected error rendering template with unknown variable")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+)
+
+// scrubbedValue replaces access tokens found in recorded exchanges
+const scrubbedValue = "[SCRUBBED]"
+
+// defaultTransport is the transport of the clients created using NewChatGPTAssistantClient.
+// it is configured once for the whole process by configureTransport, and is nil if requests
+// are sent using the default transport.
+var defaultTransport http.RoundTripper
+
+// recordedHeaders are the response headers kept in fixtures. every other header is dropped,
+// so fixtures never contain cookies, organization IDs or rate limit details.
+var recordedHeaders = []string{"Content-Type"}
+
+// Fixture is a list of HTTP exchanges recorded by a RecordingTransport, in the order they
+// were sent, and served by a ReplayTransport.
+type Fixture struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request of an interaction. request bodies and headers are never
+// recorded, as they contain the access token and the uploaded source code.
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// RecordedResponse is the response of an interaction.
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+// loadFixture reads a fixture file.
+func loadFixture(path string) (Fixture, error) {
+	var fixture Fixture
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fixture, err
+	}
+	if err := json.Unmarshal(contents, &fixture); err != nil {
+		return fixture, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+	return fixture, nil
+}
+
+// bearerToken returns the token of the Authorization header of the request, if there is one.
+func bearerToken(request *http.Request) string {
+	token, _ := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(token)
+}
+
+// scrub replaces every occurrence of the secret in the value. empty secrets are ignored.
+func scrub(value, secret string) string {
+	if len(secret) == 0 {
+		return value
+	}
+	return strings.ReplaceAll(value, secret, scrubbedValue)
+}
+
+// RecordingTransport sends requests using Transport and records every exchange to the fixture
+// file at Path. the fixture is rewritten after every exchange, so exchanges recorded before a
+// command fails are kept. The access token of each request is scrubbed from the recorded URL
+// and response body.
+type RecordingTransport struct {
+	Path string
+	// Transport sends the requests. http.DefaultTransport is used if nil
+	Transport http.RoundTripper
+	fixture   Fixture
+	mu        sync.Mutex
+}
+
+func (recorder *RecordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	transport := recorder.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	// the token is read before the request is sent, as the transport owns the request afterwards
+	token := bearerToken(request)
+	method, url := request.Method, request.URL.String()
+
+	response, err := transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// the body is read so it can be recorded, and replaced so it can still be read by the client
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: method,
+			URL:    scrub(url, token),
+		},
+		Response: RecordedResponse{
+			Status:  response.StatusCode,
+			Headers: map[string]string{},
+			Body:    scrub(string(body), token),
+		},
+	}
+	for _, header := range recordedHeaders {
+		if value := response.Header.Get(header); len(value) > 0 {
+			interaction.Response.Headers[header] = value
+		}
+	}
+
+	if err := recorder.record(interaction); err != nil {
+		log.Warn(fmt.Sprintf("error recording %s %s to %s: %+v", method, interaction.Request.URL, recorder.Path, err))
+	}
+	return response, nil
+}
+
+// record appends the interaction to the fixture and writes the fixture file.
+func (recorder *RecordingTransport) record(interaction Interaction) error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	recorder.fixture.Interactions = append(recorder.fixture.Interactions, interaction)
+	encoded, err := json.MarshalIndent(recorder.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(recorder.Path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(recorder.Path, append(encoded, '\n'), 0644)
+}
+
+// ReplayTransport serves the responses of a fixture without sending any request. each request
+// is answered with the first unused interaction with the same method and URL, so requests to
+// the same endpoint are answered in the order they were recorded. requests without a matching
+// interaction fail with a ReplayMismatchError.
+type ReplayTransport struct {
+	Path    string
+	fixture Fixture
+	used    []bool
+	mu      sync.Mutex
+}
+
+// newReplayTransport creates a transport replaying the fixture file at the given path.
+func newReplayTransport(path string) (*ReplayTransport, error) {
+	fixture, err := loadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{
+		Path:    path,
+		fixture: fixture,
+		used:    make([]bool, len(fixture.Interactions)),
+	}, nil
+}
+
+func (replay *ReplayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// the request body is consumed as the real transport would, so streamed uploads complete
+	if request.Body != nil {
+		io.Copy(io.Discard, request.Body)
+		request.Body.Close()
+	}
+	url := scrub(request.URL.String(), bearerToken(request))
+
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	for i, interaction := range replay.fixture.Interactions {
+		if replay.used[i] || interaction.Request.Method != request.Method || interaction.Request.URL != url {
+			continue
+		}
+		replay.used[i] = true
+
+		response := &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{},
+			Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       request,
+		}
+		for header, value := range interaction.Response.Headers {
+			response.Header.Set(header, value)
+		}
+		return response, nil
+	}
+	return nil, ReplayMismatchError{Path: replay.Path, Method: request.Method, URL: url}
+}
+
+// Unused returns the interactions of the fixture that have not been replayed.
+func (replay *ReplayTransport) Unused() []Interaction {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+
+	unused := []Interaction{}
+	for i, interaction := range replay.fixture.Interactions {
+		if !replay.used[i] {
+			unused = append(unused, interaction)
+		}
+	}
+	return unused
+}
+
+// configureTransport configures the transport of the process from the --record and --replay
+// flags of the command, falling back to the GOREADME_RECORD and GOREADME_REPLAY environment
+// variables. Requests are sent using the default transport if neither is set.
+func configureTransport(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	lookup := func(flag, env string) string {
+		if cmd.IsSet(flag) {
+			return cmd.String(flag)
+		}
+		return os.Getenv(env)
+	}
+	record, replay := lookup("record", "GOREADME_RECORD"), lookup("replay", "GOREADME_REPLAY")
+
+	switch {
+	case len(record) > 0 && len(replay) > 0:
+		return ctx, cli.Exit("only one of --record and --replay can be set", 1)
+	case len(record) > 0:
+		log.Debug(fmt.Sprintf("recording requests to %s", record))
+		defaultTransport = &RecordingTransport{Path: record}
+	case len(replay) > 0:
+		transport, err := newReplayTransport(replay)
+		if err != nil {
+			log.Debug(fmt.Sprintf("error loading fixture: %+v", err))
+			return ctx, cli.Exit(fmt.Sprintf("error loading fixture %s", replay), 1)
+		}
+		log.Debug(fmt.Sprintf("replaying requests from %s", replay))
+		defaultTransport = transport
+	default:
+		defaultTransport = nil
+	}
+	return ctx, nil
+}
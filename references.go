@@ -0,0 +1,425 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type ReferenceKind string
+
+const (
+	ReferenceKindPath       ReferenceKind = "path"
+	ReferenceKindFlag       ReferenceKind = "flag"
+	ReferenceKindIdentifier ReferenceKind = "identifier"
+)
+
+var (
+	inlineCodeRegex   = regexp.MustCompile("`([^`\n]+)`")
+	linkTargetRegex   = regexp.MustCompile(`\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	flagRegex         = regexp.MustCompile(`(?:^|\s)--([a-zA-Z][a-zA-Z0-9-]*)`)
+	identifierRegex   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*(\(\))?$`)
+	pathSegmentRegex  = regexp.MustCompile(`^[\w.@+-]+$`)
+	lineSuffixRegex   = regexp.MustCompile(`:\d+(-\d+)?$`)
+	majorVersionRegex = regexp.MustCompile(`^v\d+$`)
+)
+
+// builtinFlags are the flags added to every command by urfave/cli.
+var builtinFlags = []string{"help", "version"}
+
+// Reference is a file path, CLI flag or identifier mentioned in a README.
+type Reference struct {
+	Kind  ReferenceKind
+	Value string
+	Line  int
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s %s (line %d)", r.Kind, r.Value, r.Line)
+}
+
+// SymbolIndex holds the names that a README is allowed to reference for a project.
+type SymbolIndex struct {
+	// Symbols contains the names of all top level declarations, methods and struct fields
+	Symbols map[string]bool
+	// Flags contains the names and aliases of all CLI flags without leading dashes
+	Flags map[string]bool
+	// Imports contains the names of the packages imported by the project
+	Imports map[string]bool
+}
+
+// HasGoSources checks if any Go declarations were found while building the index.
+// identifiers are only validated for Go projects.
+func (index SymbolIndex) HasGoSources() bool {
+	return len(index.Symbols) > 0
+}
+
+// buildSymbolIndex parses all Go files in the root directory and collects the names of
+// declarations, methods and struct fields, along with the names of CLI flags defined
+// using urfave/cli flag structs or the standard library flag package. Files that cannot
+// be parsed are skipped.
+func buildSymbolIndex(root string) (SymbolIndex, error) {
+	index := SymbolIndex{
+		Symbols: map[string]bool{},
+		Flags:   map[string]bool{},
+		Imports: map[string]bool{},
+	}
+
+	fileSet := token.NewFileSet()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if name := d.Name(); path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fileSet, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			log.Debug(fmt.Sprintf("error parsing go file %s: %+v", path, err))
+			return nil
+		}
+		index.addFile(file)
+		return nil
+	})
+	return index, err
+}
+
+// addFile adds the declarations, imports and flag definitions of a parsed Go file to the index.
+func (index SymbolIndex) addFile(file *ast.File) {
+	for _, spec := range file.Imports {
+		if name, ok := importName(spec); ok {
+			index.Imports[name] = true
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			index.Symbols[decl.Name.Name] = true
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					index.Symbols[spec.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, name := range spec.Names {
+						index.Symbols[name.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.StructType:
+			for _, field := range node.Fields.List {
+				for _, name := range field.Names {
+					index.Symbols[name.Name] = true
+				}
+			}
+		case *ast.InterfaceType:
+			for _, method := range node.Methods.List {
+				for _, name := range method.Names {
+					index.Symbols[name.Name] = true
+				}
+			}
+		case *ast.CompositeLit:
+			index.addCliFlag(node)
+		case *ast.CallExpr:
+			index.addStdFlag(node)
+		}
+		return true
+	})
+}
+
+// addCliFlag adds the name and aliases of urfave/cli flag literals such as
+// &cli.StringFlag{Name: "target"} to the index.
+func (index SymbolIndex) addCliFlag(literal *ast.CompositeLit) {
+	selector, ok := literal.Type.(*ast.SelectorExpr)
+	if !ok || !strings.HasSuffix(selector.Sel.Name, "Flag") {
+		return
+	}
+
+	for _, element := range literal.Elts {
+		pair, ok := element.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := pair.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+
+		switch key.Name {
+		case "Name":
+			if name, ok := stringLiteral(pair.Value); ok {
+				index.Flags[name] = true
+			}
+		case "Aliases":
+			if aliases, ok := pair.Value.(*ast.CompositeLit); ok {
+				for _, alias := range aliases.Elts {
+					if name, ok := stringLiteral(alias); ok {
+						index.Flags[name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// addStdFlag adds flags defined with the standard library flag package,
+// e.g. flag.String("target", ".", "usage"), to the index.
+func (index SymbolIndex) addStdFlag(call *ast.CallExpr) {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return
+	}
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok || pkg.Name != "flag" {
+		return
+	}
+
+	// XxxVar functions take the destination pointer as the first argument
+	position := 0
+	if strings.HasSuffix(selector.Sel.Name, "Var") {
+		position = 1
+	}
+	if len(call.Args) > position {
+		if name, ok := stringLiteral(call.Args[position]); ok {
+			index.Flags[name] = true
+		}
+	}
+}
+
+// importName returns the name an import is referenced by, which is either its alias or
+// the last element of its path without any major version, e.g. cli for
+// github.com/urfave/cli/v3 and yaml for gopkg.in/yaml.v3. blank and dot imports are ignored.
+func importName(spec *ast.ImportSpec) (string, bool) {
+	if spec.Name != nil {
+		return spec.Name.Name, spec.Name.Name != "_" && spec.Name.Name != "."
+	}
+	value, ok := stringLiteral(spec.Path)
+	if !ok {
+		return "", false
+	}
+	elements := strings.Split(value, "/")
+	name := elements[len(elements)-1]
+	if majorVersionRegex.MatchString(name) && len(elements) > 1 {
+		name = elements[len(elements)-2]
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return name, len(name) > 0
+}
+
+// stringLiteral returns the value of a string literal expression.
+func stringLiteral(expr ast.Expr) (string, bool) {
+	literal, ok := expr.(*ast.BasicLit)
+	if !ok || literal.Kind != token.STRING {
+		return "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	return value, err == nil
+}
+
+// extractReferences extracts the file paths, CLI flags and identifiers referenced in
+// a README. paths and identifiers are taken from inline code spans and link targets,
+// while flags are also taken from lines of code blocks that invoke the project binary.
+//
+// Parameters:
+//   - content: The README content.
+//   - binary: The name of the project binary, used to find flags in code blocks.
+//
+// Returns:
+//   - []Reference: The references found in the content, in order of appearance.
+func extractReferences(content, binary string) []Reference {
+	references := []Reference{}
+
+	var fence fenceState
+	for i, line := range strings.Split(content, "\n") {
+		number := i + 1
+
+		if fence.Update(line) {
+			continue
+		}
+
+		if fence.Open() {
+			command := strings.TrimPrefix(strings.TrimSpace(line), "$ ")
+			if len(binary) > 0 && (command == binary || strings.HasPrefix(command, binary+" ")) {
+				references = append(references, extractFlags(command, number)...)
+			}
+			continue
+		}
+
+		for _, match := range inlineCodeRegex.FindAllStringSubmatch(line, -1) {
+			code := strings.TrimSpace(match[1])
+			if flags := extractFlags(code, number); len(flags) > 0 {
+				references = append(references, flags...)
+			} else if path, ok := asPath(code); ok {
+				references = append(references, Reference{Kind: ReferenceKindPath, Value: path, Line: number})
+			} else if isIdentifier(code) {
+				references = append(references, Reference{Kind: ReferenceKindIdentifier, Value: code, Line: number})
+			}
+		}
+
+		for _, match := range linkTargetRegex.FindAllStringSubmatch(line, -1) {
+			if path, ok := asPath(match[1]); ok {
+				references = append(references, Reference{Kind: ReferenceKindPath, Value: path, Line: number})
+			}
+		}
+	}
+	return references
+}
+
+// extractFlags returns a reference for every long flag (e.g. --config-path) in the text.
+func extractFlags(text string, line int) []Reference {
+	references := []Reference{}
+	for _, match := range flagRegex.FindAllStringSubmatch(text, -1) {
+		references = append(references, Reference{Kind: ReferenceKindFlag, Value: match[1], Line: line})
+	}
+	return references
+}
+
+// asPath checks if a code span or link target looks like a relative file path.
+// URLs, anchors, absolute and home directory paths, and import paths such as
+// github.com/user/repo are not treated as paths. Any anchor or line number
+// suffix is removed from the returned path.
+func asPath(value string) (string, bool) {
+	if strings.Contains(value, "://") || strings.HasPrefix(value, "mailto:") {
+		return "", false
+	}
+	if strings.HasPrefix(value, "#") || strings.HasPrefix(value, "/") || strings.HasPrefix(value, "~") {
+		return "", false
+	}
+
+	value, _, _ = strings.Cut(value, "#")
+	value, _, _ = strings.Cut(value, "?")
+	value = lineSuffixRegex.ReplaceAllString(value, "")
+	value = strings.TrimPrefix(value, "./")
+	if len(value) == 0 {
+		return "", false
+	}
+
+	segments := strings.Split(strings.TrimSuffix(value, "/"), "/")
+	for _, segment := range segments {
+		if !pathSegmentRegex.MatchString(segment) {
+			return "", false
+		}
+	}
+
+	if len(segments) > 1 || strings.HasSuffix(value, "/") {
+		first := segments[0]
+		// import paths start with a domain name
+		if strings.Contains(first, ".") && !strings.HasPrefix(first, ".") {
+			return "", false
+		}
+		return value, true
+	}
+
+	// single segments are only paths if they are dotfiles or have a file
+	// extension, and are not method calls or selectors such as client.Do
+	if strings.HasPrefix(value, ".") && strings.Count(value, ".") == 1 {
+		return value, true
+	}
+	ext := filepath.Ext(value)
+	if len(ext) < 2 || len(ext) > 6 || strings.ContainsFunc(ext, unicode.IsUpper) {
+		return "", false
+	}
+	if strings.Contains(strings.TrimSuffix(value, ext), ".") {
+		return "", false
+	}
+	return value, true
+}
+
+// isIdentifier checks if a code span is a Go selector, call or exported name such as
+// `client.UploadFile`, `loadConfig()` or `NewClient`. single names are only treated as
+// identifiers if they start with an upper case letter and are mixed case, so plain words
+// like `go`, `json` or `README` are ignored. keywords and predeclared names such as
+// `len()` are never identifiers.
+func isIdentifier(value string) bool {
+	if !identifierRegex.MatchString(value) {
+		return false
+	}
+	name, call := strings.CutSuffix(value, "()")
+	first, _, _ := strings.Cut(name, ".")
+	if token.IsKeyword(first) || (first == name && types.Universe.Lookup(name) != nil) {
+		return false
+	}
+	if call || first != name {
+		return true
+	}
+	return unicode.IsUpper([]rune(name)[0]) && strings.ContainsFunc(name, unicode.IsLower)
+}
+
+// validateReferences checks the references of a README against the project. paths must
+// exist in the discovered file set or on disk relative to the root directory. flags and
+// identifiers are only checked if the index contains flags or Go declarations respectively.
+//
+// Parameters:
+//   - references: The references extracted from the README.
+//   - root: The target directory of the project.
+//   - files: The discovered files, relative to the root directory.
+//   - index: The symbol index of the project.
+//
+// Returns:
+//   - []Reference: The references that do not exist in the project.
+func validateReferences(references []Reference, root string, files []string, index SymbolIndex) []Reference {
+	unknown := []Reference{}
+
+	for _, reference := range references {
+		switch reference.Kind {
+		case ReferenceKindPath:
+			if slices.Contains(files, reference.Value) {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(reference.Value))); err == nil {
+				continue
+			}
+
+		case ReferenceKindFlag:
+			if len(index.Flags) == 0 || index.Flags[reference.Value] || slices.Contains(builtinFlags, reference.Value) {
+				continue
+			}
+
+		case ReferenceKindIdentifier:
+			if !index.HasGoSources() {
+				continue
+			}
+			name := strings.TrimSuffix(reference.Value, "()")
+			parts := strings.Split(name, ".")
+			if index.Symbols[parts[len(parts)-1]] {
+				continue
+			}
+			// names of flags are not identifiers
+			if len(parts) == 1 && (index.Flags[name] || slices.Contains(builtinFlags, name)) {
+				continue
+			}
+			// selectors on imported packages, e.g. `http.Client`, cannot be checked
+			if len(parts) > 1 && index.Imports[parts[0]] {
+				continue
+			}
+		}
+
+		unknown = append(unknown, reference)
+	}
+	return unknown
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestBuildSymbolIndex tests that buildSymbolIndex collects declarations, methods,
+// struct fields, imports and urfave/cli flags using the goreadme source code itself.
+func TestBuildSymbolIndex(t *testing.T) {
+	index, err := buildSymbolIndex(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, symbol := range []string{"Config", "AccessToken", "loadConfig", "UploadFile", "APIUrl"} {
+		if !index.Symbols[symbol] {
+			t.Errorf("expected symbol %s in index", symbol)
+		}
+	}
+
+	for _, flag := range []string{"log-level", "config-path", "target"} {
+		if !index.Flags[flag] {
+			t.Errorf("expected flag %s in index", flag)
+		}
+	}
+
+	// imports are indexed by alias, or by path without the major version
+	for _, name := range []string{"log", "cli", "yaml", "filepath"} {
+		if !index.Imports[name] {
+			t.Errorf("expected import %s in index", name)
+		}
+	}
+}
+
+// TestAsPath tests that asPath identifies relative file paths and ignores URLs,
+// import paths, selectors and other code spans.
+func TestAsPath(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{value: "main.go", want: "main.go", ok: true},
+		{value: "./docs/usage.md#install", want: "docs/usage.md", ok: true},
+		{value: "tests/src/main.py:12", want: "tests/src/main.py", ok: true},
+		{value: ".env", want: ".env", ok: true},
+		{value: "tests/", want: "tests/", ok: true},
+		{value: "https://example.com/docs", ok: false},
+		{value: "github.com/urfave/cli", ok: false},
+		{value: "~/.goreadme/config.json", ok: false},
+		{value: "client.Do", ok: false},
+		{value: "go build .", ok: false},
+		{value: "#usage", ok: false},
+		{value: "v1.2.3", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			got, ok := asPath(test.value)
+			if ok != test.ok || got != test.want {
+				t.Errorf("got: %s %v, want: %s %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+// TestExtractReferences tests that extractReferences finds paths, flags and identifiers
+// in inline code and links, and flags in code blocks that invoke the project binary.
+func TestExtractReferences(t *testing.T) {
+	content := "# Title\n\n" +
+		"Run `goreadme generate --target .` to read `main.go` and [docs](docs/usage.md).\n" +
+		"The `Config` struct, `client.UploadFile` and `README` file.\n\n" +
+		"```bash\n$ goreadme --log-level DEBUG test\n$ go build --trimpath\n```\n"
+
+	got := []string{}
+	for _, reference := range extractReferences(content, "goreadme") {
+		got = append(got, reference.String())
+	}
+
+	want := []string{
+		"flag target (line 3)",
+		"path main.go (line 3)",
+		"path docs/usage.md (line 3)",
+		"identifier Config (line 4)",
+		"identifier client.UploadFile (line 4)",
+		"flag log-level (line 7)",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+// TestIsIdentifier tests that selectors, calls and exported mixed case names are treated
+// as identifiers, while plain words and builtins in inline code are not.
+func TestIsIdentifier(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"client.UploadFile", true},
+		{"loadConfig()", true},
+		{"Config.Missing()", true},
+		{"http.Client", true},
+		{"Config", true},
+		{"NewClient", true},
+		{"README", false},
+		{"JSON", false},
+		{"go", false},
+		{"true", false},
+		{"json", false},
+		{"nil", false},
+		{"maxTokens", false},
+		{"len()", false},
+		{"func()", false},
+		{"go.mod()", false},
+		{"config-path", false},
+		{"Config struct", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if got := isIdentifier(test.value); got != test.want {
+				t.Errorf("got: %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestValidateReferences tests that validateReferences reports references that do not
+// exist in the project, and ignores references that cannot be checked.
+func TestValidateReferences(t *testing.T) {
+	index := SymbolIndex{
+		Symbols: map[string]bool{"Config": true, "loadConfig": true},
+		Flags:   map[string]bool{"target": true, "DryRun": true},
+		Imports: map[string]bool{"http": true},
+	}
+
+	references := []Reference{
+		{Kind: ReferenceKindPath, Value: "tests/src/main.py", Line: 1},
+		{Kind: ReferenceKindPath, Value: "nested/example.py", Line: 2},
+		{Kind: ReferenceKindPath, Value: "docs/missing.md", Line: 3},
+		{Kind: ReferenceKindFlag, Value: "target", Line: 4},
+		{Kind: ReferenceKindFlag, Value: "help", Line: 5},
+		{Kind: ReferenceKindFlag, Value: "output", Line: 6},
+		{Kind: ReferenceKindIdentifier, Value: "loadConfig()", Line: 7},
+		{Kind: ReferenceKindIdentifier, Value: "http.Client", Line: 8},
+		{Kind: ReferenceKindIdentifier, Value: "Config.Missing", Line: 9},
+		{Kind: ReferenceKindIdentifier, Value: "MissingFunction()", Line: 10},
+		{Kind: ReferenceKindIdentifier, Value: "Config", Line: 11},
+		{Kind: ReferenceKindIdentifier, Value: "NewClient", Line: 12},
+		{Kind: ReferenceKindIdentifier, Value: "client.UploadFiles", Line: 13},
+		{Kind: ReferenceKindIdentifier, Value: "client.loadConfig()", Line: 14},
+		{Kind: ReferenceKindIdentifier, Value: "DryRun", Line: 15},
+	}
+
+	unknown := validateReferences(references, ".", []string{"nested/example.py"}, index)
+
+	got := []int{}
+	for _, reference := range unknown {
+		got = append(got, reference.Line)
+	}
+	if !slices.Equal(got, []int{3, 6, 9, 10, 12, 13}) {
+		t.Errorf("got: %v, want: %v", got, []int{3, 6, 9, 10, 12, 13})
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RunReport summarises what happened to the files of the target directory during a run
+// of generate, and is printed once the run has finished.
+type RunReport struct {
+	// Skipped are the paths that were skipped when discovering files
+	Skipped []SkippedPath `json:"skipped"`
+	// Secrets are the secrets found in the files to upload
+	Secrets []SecretFinding `json:"secrets"`
+	// SecretsRedacted is true if the secrets were redacted, rather than aborting the run
+	SecretsRedacted bool `json:"secretsRedacted"`
+}
+
+// String formats the report, one line per entry. An empty string is returned if there is
+// nothing to report.
+func (report RunReport) String() string {
+	var builder strings.Builder
+	if len(report.Skipped) > 0 {
+		builder.WriteString(fmt.Sprintf("skipped %d paths:\n", len(report.Skipped)))
+		for _, skipped := range report.Skipped {
+			builder.WriteString(fmt.Sprintf("  %s\n", skipped))
+		}
+	}
+	if len(report.Secrets) > 0 {
+		action := "found"
+		if report.SecretsRedacted {
+			action = "redacted"
+		}
+		builder.WriteString(fmt.Sprintf("%s %d secrets:\n", action, len(report.Secrets)))
+		for _, finding := range report.Secrets {
+			builder.WriteString(fmt.Sprintf("  %s\n", finding))
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SecretsModeRedact replaces secrets with a placeholder before files are uploaded
+	SecretsModeRedact = "redact"
+	// SecretsModeAbort stops generation before any file is uploaded if a secret is found
+	SecretsModeAbort = "abort"
+	// SecretsModeOff uploads files without scanning them
+	SecretsModeOff = "off"
+
+	// secretAllowlistPragma marks lines that are never redacted, matching detect-secrets
+	secretAllowlistPragma = "pragma: allowlist secret"
+)
+
+var (
+	secretsModes = []string{SecretsModeRedact, SecretsModeAbort, SecretsModeOff}
+
+	privateKeyStartRegex = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEndRegex   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+)
+
+// SecretPattern is a custom secret pattern of the project config.
+type SecretPattern struct {
+	// Name is the name of the pattern, used in the placeholder of redacted secrets
+	Name string `yaml:"name"`
+	// Pattern is the regular expression matching the secret. if the expression contains a
+	// group, only the first group is redacted
+	Pattern string `yaml:"pattern"`
+	// Entropy is the minimum shannon entropy of a match. 0 redacts every match
+	Entropy float64 `yaml:"entropy"`
+}
+
+// SecretRule is a compiled rule used to find secrets in the contents of files.
+type SecretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Entropy is the minimum shannon entropy of a match, used to ignore placeholders
+	// and other values that are unlikely to be secrets
+	Entropy float64
+}
+
+// defaultSecretRules are the built in rules, covering the most common key formats.
+var defaultSecretRules = []SecretRule{
+	{Name: "aws-key", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`)},
+	{Name: "aws-secret", Pattern: regexp.MustCompile(`(?i)aws.{0,20}?(?:secret|key).{0,20}?['"]([0-9a-zA-Z/+]{40})['"]`), Entropy: 3.5},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{40,})\b`)},
+	{Name: "openai-key", Pattern: regexp.MustCompile(`\b(sk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{20,})`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\b(xox[abposr]-[A-Za-z0-9-]{10,})`)},
+	{Name: "stripe-key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_live_[A-Za-z0-9]{24,})\b`)},
+	{Name: "google-api-key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,})`)},
+	{Name: "basic-auth", Pattern: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^\s:@/]+:([^\s:@/]+)@`)},
+	// quoted values assigned to secret like names, and unquoted values of .env style variables
+	{Name: "generic-secret", Pattern: regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|passw(?:or)?d|credentials?)["']?\s*[:=]+\s*["']([^\s"']{12,})["']`), Entropy: 3.5},
+	{Name: "env-secret", Pattern: regexp.MustCompile(`^\s*(?:export\s+)?[A-Z0-9_]*(?:KEY|SECRET|TOKEN|PASSWORD|PASSWD)[A-Z0-9_]*\s*=\s*([^\s"'#]{12,})`), Entropy: 3.5},
+}
+
+// SecretFinding is a secret found in a file.
+type SecretFinding struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+}
+
+func (finding SecretFinding) String() string {
+	return fmt.Sprintf("%s:%d: %s", finding.Path, finding.Line, finding.Rule)
+}
+
+// newSecretRules compiles the custom patterns, returning them after the default rules.
+//
+// Parameters:
+//   - patterns: The custom patterns of the project config.
+//
+// Returns:
+//   - []SecretRule: The default rules followed by the custom rules.
+//   - error: An error if a pattern does not have a name or cannot be compiled.
+func newSecretRules(patterns []SecretPattern) ([]SecretRule, error) {
+	rules := slices.Clone(defaultSecretRules)
+	for i, pattern := range patterns {
+		if len(pattern.Name) == 0 {
+			return nil, fmt.Errorf("secret pattern %d does not have a name", i+1)
+		}
+		compiled, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret pattern %s: %w", pattern.Name, err)
+		}
+		rules = append(rules, SecretRule{
+			Name:    pattern.Name,
+			Pattern: compiled,
+			Entropy: pattern.Entropy,
+		})
+	}
+	return rules, nil
+}
+
+// shannonEntropy returns the shannon entropy of the characters of the value, in bits.
+func shannonEntropy(value string) float64 {
+	if len(value) == 0 {
+		return 0
+	}
+	counts := map[rune]int{}
+	for _, char := range value {
+		counts[char]++
+	}
+
+	length := float64(len([]rune(value)))
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// secretRedactor redacts secrets from the lines of a single file. Private keys span
+// multiple lines, so the redactor keeps track of whether it is inside a private key.
+type secretRedactor struct {
+	rules        []SecretRule
+	inPrivateKey bool
+}
+
+// redactLine replaces the secrets of a line with [REDACTED:<rule>] placeholders.
+//
+// Parameters:
+//   - line: The line to redact, including any line ending.
+//
+// Returns:
+//   - string: The redacted line.
+//   - []string: The names of the rules of each redacted secret.
+func (redactor *secretRedactor) redactLine(line string) (string, []string) {
+	if strings.Contains(line, secretAllowlistPragma) {
+		return line, nil
+	}
+
+	content := strings.TrimRight(line, "\r\n")
+	ending := line[len(content):]
+
+	if redactor.inPrivateKey || privateKeyStartRegex.MatchString(content) {
+		redactor.inPrivateKey = !privateKeyEndRegex.MatchString(content)
+		if len(strings.TrimSpace(content)) == 0 {
+			return line, nil
+		}
+		return "[REDACTED:private-key]" + ending, []string{"private-key"}
+	}
+
+	found := []string{}
+	for _, rule := range redactor.rules {
+		var builder strings.Builder
+		last := 0
+		for _, match := range rule.Pattern.FindAllStringSubmatchIndex(content, -1) {
+			// only the first group is redacted, so the surrounding context is kept
+			start, end := match[0], match[1]
+			if len(match) > 3 && match[2] >= 0 {
+				start, end = match[2], match[3]
+			}
+			secret := content[start:end]
+			if strings.Contains(secret, "[REDACTED:") || (rule.Entropy > 0 && shannonEntropy(secret) < rule.Entropy) {
+				continue
+			}
+
+			builder.WriteString(content[last:start])
+			builder.WriteString(fmt.Sprintf("[REDACTED:%s]", rule.Name))
+			last = end
+			found = append(found, rule.Name)
+		}
+		builder.WriteString(content[last:])
+		content = builder.String()
+	}
+	return content + ending, found
+}
+
+// redactingReader redacts the secrets of a file as it is read, one line at a time,
+// so files are never held in memory.
+type redactingReader struct {
+	source   io.ReadCloser
+	reader   *bufio.Reader
+	redactor *secretRedactor
+	pending  []byte
+	err      error
+}
+
+func (reader *redactingReader) Read(p []byte) (int, error) {
+	for len(reader.pending) == 0 && reader.err == nil {
+		line, err := reader.reader.ReadString('\n')
+		redacted, _ := reader.redactor.redactLine(line)
+		reader.pending = []byte(redacted)
+		reader.err = err
+	}
+
+	if len(reader.pending) == 0 {
+		return 0, reader.err
+	}
+	n := copy(p, reader.pending)
+	reader.pending = reader.pending[n:]
+	return n, nil
+}
+
+func (reader *redactingReader) Close() error {
+	return reader.source.Close()
+}
+
+// scanSourceFile finds the secrets of a single file, reading it one line at a time.
+func scanSourceFile(file SourceFile, rules []SecretRule) ([]SecretFinding, error) {
+	content, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	findings := []SecretFinding{}
+	redactor := &secretRedactor{rules: rules}
+	reader := bufio.NewReader(content)
+	for number := 1; ; number++ {
+		line, err := reader.ReadString('\n')
+		_, found := redactor.redactLine(line)
+		for _, rule := range found {
+			findings = append(findings, SecretFinding{
+				Path: file.Path,
+				Line: number,
+				Rule: rule,
+			})
+		}
+		if errors.Is(err, io.EOF) {
+			return findings, nil
+		} else if err != nil {
+			return findings, err
+		}
+	}
+}
+
+// redactSourceFile returns a source file whose contents are redacted as they are read.
+func redactSourceFile(file SourceFile, rules []SecretRule) SourceFile {
+	open := file.Open
+	file.Open = func() (io.ReadCloser, error) {
+		source, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return &redactingReader{
+			source:   source,
+			reader:   bufio.NewReader(source),
+			redactor: &secretRedactor{rules: rules},
+		}, nil
+	}
+	return file
+}
+
+// scanSecrets scans the files for secrets before they are uploaded. In redact mode every
+// file is replaced by a file that is redacted as it is read, so secrets added to a file
+// after it was scanned never leave the machine either. The scan itself is only used to
+// report the secrets, and to stop before uploading in abort mode.
+//
+// Parameters:
+//   - files: The files to upload, keyed by the name they are uploaded with.
+//   - rules: The rules used to find secrets.
+//   - mode: The secrets mode, either redact or abort.
+//
+// Returns:
+//   - map[string]SourceFile: The files to upload, redacted as they are read in redact mode.
+//   - []SecretFinding: The secrets that were found, sorted by path and line.
+//   - error: An error if a file cannot be read, or a SecretsFoundError in abort mode.
+func scanSecrets(files map[string]SourceFile, rules []SecretRule, mode string) (map[string]SourceFile, []SecretFinding, error) {
+	scanned := map[string]SourceFile{}
+	findings := []SecretFinding{}
+	for name, file := range files {
+		found, err := scanSourceFile(file, rules)
+		if err != nil {
+			return files, findings, fmt.Errorf("error scanning %s for secrets: %w", file.Path, err)
+		}
+
+		if len(found) > 0 {
+			log.Debug(fmt.Sprintf("found %d secrets in %s", len(found), file.Path))
+			findings = append(findings, found...)
+		}
+
+		scanned[name] = file
+		if mode == SecretsModeRedact {
+			scanned[name] = redactSourceFile(file, rules)
+		}
+	}
+
+	slices.SortFunc(findings, func(a, b SecretFinding) int {
+		if a.Path != b.Path {
+			return strings.Compare(a.Path, b.Path)
+		}
+		return a.Line - b.Line
+	})
+
+	if mode == SecretsModeAbort && len(findings) > 0 {
+		return files, findings, SecretsFoundError{Findings: findings}
+	}
+	return scanned, findings, nil
+}
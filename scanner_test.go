@@ -0,0 +1,193 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// the secrets of the tests are split so that they are not flagged by detect-secrets
+var (
+	testAWSKey      = "AKIA" + "Z7QW4LMN2PXK8RTD"
+	testGitHubToken = "ghp_" + "r8Kq2LmXv9Tz4WnB7pYc1HdF6sJg3QeA0uNi"
+	testPassword    = "hX7qL2v!" + "Rz9@pT4w"
+)
+
+// TestRedactLine tests that secrets are replaced with placeholders naming the rule, and
+// that placeholders, low entropy values and allowlisted lines are left as is.
+func TestRedactLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+		found    []string
+	}{
+		{
+			name:     "aws key",
+			line:     "key = \"" + testAWSKey + "\"\n",
+			expected: "key = \"[REDACTED:aws-key]\"\n",
+			found:    []string{"aws-key"},
+		},
+		{
+			name:     "github token",
+			line:     "GITHUB=" + testGitHubToken,
+			expected: "GITHUB=[REDACTED:github-token]",
+			found:    []string{"github-token"},
+		},
+		{
+			name:     "quoted password",
+			line:     "  \"password\": \"" + testPassword + "\",\r\n",
+			expected: "  \"password\": \"[REDACTED:generic-secret]\",\r\n",
+			found:    []string{"generic-secret"},
+		},
+		{
+			name:     "env file",
+			line:     "export DB_PASSWORD=" + testPassword + "\n",
+			expected: "export DB_PASSWORD=[REDACTED:env-secret]\n",
+			found:    []string{"env-secret"},
+		},
+		{
+			name:     "low entropy",
+			line:     "password = \"aaaaaaaaaaaaaaaa\"\n",
+			expected: "password = \"aaaaaaaaaaaaaaaa\"\n",
+		},
+		{
+			name:     "code",
+			line:     "token := client.Credentials.Secret\n",
+			expected: "token := client.Credentials.Secret\n",
+		},
+		{
+			name:     "allowlisted",
+			line:     "key = \"" + testAWSKey + "\" # " + secretAllowlistPragma + "\n",
+			expected: "key = \"" + testAWSKey + "\" # " + secretAllowlistPragma + "\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			redactor := &secretRedactor{rules: defaultSecretRules}
+			redacted, found := redactor.redactLine(test.line)
+			if redacted != test.expected {
+				t.Errorf("got: %q, want: %q", redacted, test.expected)
+			}
+			if !slices.Equal(found, test.found) {
+				t.Errorf("got: %v, want: %v", found, test.found)
+			}
+		})
+	}
+}
+
+// TestScanSecrets tests that files containing secrets are reported with the line of each
+// secret and redacted as they are read, including private keys spanning multiple lines.
+func TestScanSecrets(t *testing.T) {
+	config := "[default]\naws_access_key_id = " + testAWSKey + "\nregion = eu-west-1\n"
+	key := "-----BEGIN RSA " + "PRIVATE KEY-----\nMIIEpAIBAAKCAQEA\n-----END RSA " + "PRIVATE KEY-----\nprint('done')\n"
+	files := map[string]SourceFile{
+		"config.py": newTestSourceFile("config.py", config),
+		"key.py":    newTestSourceFile("key.py", key),
+		"main.py":   newTestSourceFile("main.py", "print('main')\n"),
+	}
+
+	scanned, findings, err := scanSecrets(files, defaultSecretRules, SecretsModeRedact)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SecretFinding{
+		{Path: "config.py", Line: 2, Rule: "aws-key"},
+		{Path: "key.py", Line: 1, Rule: "private-key"},
+		{Path: "key.py", Line: 2, Rule: "private-key"},
+		{Path: "key.py", Line: 3, Rule: "private-key"},
+	}
+	if !slices.Equal(findings, expected) {
+		t.Errorf("got: %v, want: %v", findings, expected)
+	}
+
+	contents := map[string]string{
+		"config.py": "[default]\naws_access_key_id = [REDACTED:aws-key]\nregion = eu-west-1\n",
+		"key.py":    "[REDACTED:private-key]\n[REDACTED:private-key]\n[REDACTED:private-key]\nprint('done')\n",
+		"main.py":   "print('main')\n",
+	}
+	for name, want := range contents {
+		reader, err := scanned[name].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s got: %q, want: %q", name, got, want)
+		}
+	}
+
+	var secretsFound SecretsFoundError
+	if _, _, err := scanSecrets(files, defaultSecretRules, SecretsModeAbort); !errors.As(err, &secretsFound) {
+		t.Errorf("got: %v, want: %v", err, SecretsFoundError{Findings: expected})
+	}
+}
+
+// TestScanSecretsChangedFile tests that in redact mode files without secrets when they are
+// scanned are still redacted if a secret is added before they are uploaded.
+func TestScanSecretsChangedFile(t *testing.T) {
+	content := "print('main')\n"
+	files := map[string]SourceFile{
+		"main.py": {
+			Path: "main.py",
+			Open: func() (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(content)), nil
+			},
+		},
+	}
+
+	scanned, findings, err := scanSecrets(files, defaultSecretRules, SecretsModeRedact)
+	if err != nil || len(findings) > 0 {
+		t.Fatalf("got: %v %v, want no findings", findings, err)
+	}
+
+	// the file is edited between the scan and the upload
+	content = "aws_access_key_id = " + testAWSKey + "\n"
+	reader, err := scanned["main.py"].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "aws_access_key_id = [REDACTED:aws-key]\n"; string(got) != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+// TestNewSecretRules tests that custom patterns are added after the built in rules, and
+// that patterns without a name or with an invalid expression are rejected.
+func TestNewSecretRules(t *testing.T) {
+	rules, err := newSecretRules([]SecretPattern{{Name: "internal-token", Pattern: `itk_[a-z0-9]{8}`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != len(defaultSecretRules)+1 {
+		t.Fatalf("got: %d, want: %d", len(rules), len(defaultSecretRules)+1)
+	}
+
+	redactor := &secretRedactor{rules: rules}
+	if redacted, _ := redactor.redactLine("auth(itk_abcd1234)"); redacted != "auth([REDACTED:internal-token])" {
+		t.Errorf("got: %s, want: %s", redacted, "auth([REDACTED:internal-token])")
+	}
+
+	invalid := [][]SecretPattern{
+		{{Pattern: `itk_[a-z0-9]{8}`}},
+		{{Name: "broken", Pattern: `itk_[`}},
+	}
+	for _, patterns := range invalid {
+		if _, err := newSecretRules(patterns); err == nil || !strings.Contains(err.Error(), "secret pattern") {
+			t.Errorf("expected error for patterns %+v, got: %v", patterns, err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	SecretsFileName = "secrets.json"
+
+	// TokenStorePlaintext stores the access token in the config file
+	TokenStorePlaintext = "plaintext"
+	// TokenStoreEncrypted stores the access token in the passphrase encrypted secrets file
+	TokenStoreEncrypted = "encrypted"
+)
+
+// SecretSource resolves secret references with a given scheme, e.g. env:OPENAI_API_KEY.
+type SecretSource interface {
+	Resolve(value string) (string, error)
+}
+
+// EnvSecretSource resolves references to environment variables, e.g. env:OPENAI_API_KEY.
+type EnvSecretSource struct{}
+
+func (source EnvSecretSource) Resolve(name string) (string, error) {
+	value := os.Getenv(name)
+	if len(value) == 0 {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// HelperSecretSource resolves secrets by running a credential helper command, similar
+// to the credential.helper setting of git, e.g. helper:pass show openai. The secret is
+// read from the first line of the output of the command.
+type HelperSecretSource struct{}
+
+func (source HelperSecretSource) Resolve(command string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+
+	helper := exec.Command(shell, flag, command)
+	helper.Stderr = os.Stderr
+	output, err := helper.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running credential helper: %w", err)
+	}
+
+	secret, _, _ := strings.Cut(string(output), "\n")
+	secret = strings.TrimSpace(secret)
+	if len(secret) == 0 {
+		return "", errors.New("credential helper did not return a secret")
+	}
+	return secret, nil
+}
+
+// EncryptedSecret is a secret encrypted with a key derived from a passphrase.
+type EncryptedSecret struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedSecretSource resolves references to secrets stored in a local secrets file,
+// e.g. secrets:default. Secrets are encrypted using AES-GCM, with a key derived from the
+// passphrase using scrypt.
+type EncryptedSecretSource struct {
+	Path string
+	// Passphrase returns the passphrase used to encrypt and decrypt secrets
+	Passphrase func() (string, error)
+}
+
+func (source EncryptedSecretSource) Resolve(name string) (string, error) {
+	secrets, err := source.read()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found in %s", name, source.Path)
+	}
+
+	passphrase, err := source.Passphrase()
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newSecretCipher(passphrase, secret.Salt)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := aead.Open(nil, secret.Nonce, secret.Ciphertext, []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("error decrypting secret %s: incorrect passphrase or corrupted secrets file", name)
+	}
+	return string(plaintext), nil
+}
+
+// Store encrypts the secret and stores it in the secrets file under the given name.
+// the secrets file is written with permissions that only allow the owner to read it.
+func (source EncryptedSecretSource) Store(name, value string) error {
+	secrets, err := source.read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	passphrase, err := source.Passphrase()
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	aead, err := newSecretCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	secrets[name] = EncryptedSecret{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, []byte(value), []byte(name)),
+	}
+
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(source.Path), 0700); err != nil {
+		return err
+	}
+	return writePrivateFile(source.Path, data)
+}
+
+// read reads the encrypted secrets of the secrets file.
+func (source EncryptedSecretSource) read() (map[string]EncryptedSecret, error) {
+	secrets := map[string]EncryptedSecret{}
+
+	contents, err := os.ReadFile(source.Path)
+	if err != nil {
+		return secrets, err
+	}
+	if err := json.Unmarshal(contents, &secrets); err != nil {
+		return secrets, fmt.Errorf("error decoding secrets file %s: %w", source.Path, err)
+	}
+	return secrets, nil
+}
+
+// newSecretCipher derives a key from the passphrase and salt, and creates an AES-GCM cipher using the key.
+func newSecretCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// readPassphrase returns the passphrase of the secrets file from the GOREADME_PASSPHRASE
+// environment variable, prompting for the passphrase if stdin is a terminal.
+func readPassphrase() (string, error) {
+	if passphrase := os.Getenv("GOREADME_PASSPHRASE"); len(passphrase) > 0 {
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("GOREADME_PASSPHRASE must be set to decrypt secrets when not running in a terminal")
+	}
+
+	fmt.Fprint(os.Stderr, "Enter secrets passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	return string(passphrase), err
+}
+
+// newSecretSources creates the secret sources used to resolve references in the config
+// file at the given path, keyed by their scheme. the encrypted secrets file is kept in
+// the same directory as the config file.
+func newSecretSources(configPath string) map[string]SecretSource {
+	return map[string]SecretSource{
+		"env":     EnvSecretSource{},
+		"helper":  HelperSecretSource{},
+		"secrets": newEncryptedSecretSource(configPath),
+	}
+}
+
+// newEncryptedSecretSource creates the source of the secrets file of the config file at the given path.
+func newEncryptedSecretSource(configPath string) EncryptedSecretSource {
+	return EncryptedSecretSource{
+		Path:       filepath.Join(filepath.Dir(configPath), SecretsFileName),
+		Passphrase: readPassphrase,
+	}
+}
+
+// parseSecretReference splits a reference to a secret into the scheme of the secret source
+// and the value passed to the source. false is returned for values that are not references.
+func parseSecretReference(value string, sources map[string]SecretSource) (SecretSource, string, bool) {
+	scheme, rest, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, "", false
+	}
+
+	source, ok := sources[scheme]
+	return source, rest, ok
+}
+
+// isSecretReference checks if the value is a reference to a secret rather than a plaintext secret.
+func isSecretReference(value string) bool {
+	_, _, ok := parseSecretReference(value, newSecretSources(""))
+	return ok
+}
+
+// resolveSecret resolves a reference to a secret using the provided sources. values
+// that are not references are plaintext secrets, and are returned as is.
+func resolveSecret(value string, sources map[string]SecretSource) (string, error) {
+	source, reference, ok := parseSecretReference(value, sources)
+	if !ok {
+		return value, nil
+	}
+
+	secret, err := source.Resolve(reference)
+	if err != nil {
+		return "", SecretResolutionError{Reference: value, Err: err}
+	}
+	return secret, nil
+}
+
+// writePrivateFile writes a file that can only be read and written by the owner. the
+// data is written to a temporary file created with owner only permissions, which then
+// replaces the file, so the data is never readable by other users, even if an existing
+// file was.
+func writePrivateFile(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := file.Chmod(0600); err != nil && runtime.GOOS != "windows" {
+		file.Close()
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// readableWarnings contains the paths of the files that warnIfReadableByOthers has warned
+// about. the config file is read more than once by most commands, so each file is only
+// warned about once per process.
+var readableWarnings sync.Map
+
+// warnIfReadableByOthers logs a warning if the file at the path can be read by other users.
+// the warning is only logged the first time a file is checked.
+func warnIfReadableByOthers(path string, info os.FileInfo) {
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0044 != 0 {
+		if _, warned := readableWarnings.LoadOrStore(path, true); warned {
+			return
+		}
+		log.Warn(fmt.Sprintf("config file %s can be read by other users, restrict its permissions using chmod 600 %s", path, path))
+	}
+}
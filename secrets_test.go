@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TestResolveSecret tests resolving plaintext secrets and references to
+// environment variables and credential helpers.
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("GOREADME_TEST_SECRET", "env-secret")
+	sources := newSecretSources(filepath.Join(t.TempDir(), "config.json"))
+
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "plaintext-token", want: "plaintext-token"},
+		{value: "sk-proj:with-colon", want: "sk-proj:with-colon"},
+		{value: "env:GOREADME_TEST_SECRET", want: "env-secret"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			value string
+			want  string
+		}{value: "helper:printf 'helper-secret\\nignored'", want: "helper-secret"})
+	}
+
+	for _, test := range tests {
+		got, err := resolveSecret(test.value, sources)
+		if err != nil {
+			t.Errorf("error resolving %s: %v", test.value, err)
+		} else if got != test.want {
+			t.Errorf("got: %s, want: %s", got, test.want)
+		}
+	}
+
+	_, err := resolveSecret("env:GOREADME_TEST_SECRET_MISSING", sources)
+	var resolutionErr SecretResolutionError
+	if !errors.As(err, &resolutionErr) {
+		t.Errorf("expected SecretResolutionError, got %+v", err)
+	}
+}
+
+// TestEncryptedSecretSource tests that secrets stored in the secrets file can only be
+// decrypted using the same passphrase, and that the file is only readable by the owner.
+func TestEncryptedSecretSource(t *testing.T) {
+	passphrase := "correct horse battery staple"
+	source := EncryptedSecretSource{
+		Path: filepath.Join(t.TempDir(), SecretsFileName),
+		Passphrase: func() (string, error) {
+			return passphrase, nil
+		},
+	}
+
+	if err := source.Store("default", "secret-token"); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.Store("internal", "internal-token"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{"default": "secret-token", "internal": "internal-token"} {
+		got, err := source.Resolve(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got: %s, want: %s", got, want)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(source.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got: %v, want: %v", info.Mode().Perm(), os.FileMode(0600))
+		}
+	}
+
+	passphrase = "wrong passphrase"
+	if _, err := source.Resolve("default"); err == nil {
+		t.Error("expected error decrypting secret with wrong passphrase")
+	}
+}
+
+// TestLoadConfigSecretReference tests that access token references in the config file
+// are kept when the config is loaded and only resolved by resolveAccessToken, and that
+// written config files are private.
+func TestLoadConfigSecretReference(t *testing.T) {
+	t.Setenv("GOREADME_TEST_TOKEN", "env-token")
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	config := Config{
+		AccessToken:   "env:GOREADME_TEST_TOKEN",
+		ModelVersion:  "test-model",
+		AssistantId:   "assistant_test-id",
+		VectorStoreId: "vectorstore_test-id",
+	}
+	if err := writeConfig(config, path, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadConfig(path, "", ConfigOverrides{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.AccessToken != config.AccessToken {
+		t.Errorf("got: %s, want: %s", loaded.AccessToken, config.AccessToken)
+	}
+
+	loaded, err = resolveAccessToken(loaded, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.AccessToken != "env-token" {
+		t.Errorf("got: %s, want: %s", loaded.AccessToken, "env-token")
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got: %v, want: %v", info.Mode().Perm(), os.FileMode(0600))
+		}
+	}
+}
+
+// TestWritePrivateFile tests that existing files readable by other users are replaced by
+// a file only the owner can read, without leaving temporary files behind.
+func TestWritePrivateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writePrivateFile(path, []byte(`{"accessToken": "token"}`)); err != nil {
+		t.Fatal(err)
+	}
+	contents, err := os.ReadFile(path)
+	if err != nil || string(contents) != `{"accessToken": "token"}` {
+		t.Errorf("got: %s %v, want: %s", contents, err, `{"accessToken": "token"}`)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("got: %v, want: %v", info.Mode().Perm(), os.FileMode(0600))
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 1 {
+		t.Errorf("expected only %s to exist, got: %v %v", path, entries, err)
+	}
+}
+
+// TestWarnIfReadableByOthers tests that a config file readable by other users is only
+// warned about once, although it is read more than once.
+func TestWarnIfReadableByOthers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not checked on windows")
+	}
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"version": 2, "profiles": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	for i := 0; i < 3; i++ {
+		if _, err := readConfigFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if warnings := strings.Count(output.String(), "can be read by other users"); warnings != 1 {
+		t.Errorf("got: %d, want: %d", warnings, 1)
+	}
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	SettingSourceDefault = "default"
+
+	DefaultOutput = "README.md"
+)
+
+// Settings are the effective settings used to generate a README, resolved from the
+// built in defaults, the global config, the project config, environment variables
+// and CLI flags, in increasing order of precedence.
+type Settings struct {
+	Model string
+	// Include contains patterns of files to upload. all discovered files are uploaded if empty
+	Include []string
+	// Exclude contains patterns of files that are never uploaded
+	Exclude []string
+	// Output is the path the README is written to, relative to the target directory
+	Output string
+	// PromptTemplate is the path of the prompt template, relative to the target directory.
+	// the template is discovered using loadPromptTemplate if empty
+	PromptTemplate string
+	Sections       []OutlineSection
+	// MaxFileSize is the maximum size in bytes of a single uploaded file. 0 disables the limit
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size in bytes of all uploaded files. 0 disables the limit
+	MaxTotalSize int64
+	// Concurrency is the number of files uploaded or deleted at the same time
+	Concurrency int
+	// FollowSymlinks follows symlinks when discovering files
+	FollowSymlinks bool
+	// SameFilesystem skips paths on a different filesystem to the target directory when discovering files
+	SameFilesystem bool
+	// Secrets is the secrets mode, either redact, abort or off
+	Secrets string
+	// SecretPatterns are the custom secret patterns, used in addition to the built in rules
+	SecretPatterns []SecretPattern
+	// Sources maps the name of each setting to the source it was resolved from
+	Sources map[string]string
+}
+
+// settingNames are the names of all settings, in the order they are displayed.
+var settingNames = []string{"model", "include", "exclude", "output", "prompt-template", "sections", "max-file-size", "max-total-size", "concurrency", "follow-symlinks", "same-filesystem", "secrets", "secret-patterns"}
+
+// settingEnvVars maps the name of each setting that can be set using an environment variable to the variable.
+var settingEnvVars = map[string]string{
+	"model":           "GOREADME_MODEL",
+	"include":         "GOREADME_INCLUDE",
+	"exclude":         "GOREADME_EXCLUDE",
+	"output":          "GOREADME_OUTPUT",
+	"prompt-template": "GOREADME_PROMPT_TEMPLATE",
+	"sections":        "GOREADME_SECTIONS",
+	"max-file-size":   "GOREADME_MAX_FILE_SIZE",
+	"max-total-size":  "GOREADME_MAX_TOTAL_SIZE",
+	"concurrency":     "GOREADME_CONCURRENCY",
+	"follow-symlinks": "GOREADME_FOLLOW_SYMLINKS",
+	"same-filesystem": "GOREADME_SAME_FILESYSTEM",
+	"secrets":         "GOREADME_SECRETS",
+}
+
+// newSettings creates the settings containing the built in defaults.
+func newSettings() Settings {
+	settings := Settings{
+		Output:      DefaultOutput,
+		Concurrency: DefaultConcurrency,
+		Secrets:     SecretsModeRedact,
+		Sources:     map[string]string{},
+	}
+	for _, name := range settingNames {
+		settings.Sources[name] = SettingSourceDefault
+	}
+	return settings
+}
+
+// set updates a setting if the value is not empty, recording the source of the value.
+func set[T any](settings *Settings, name, source string, field *T, value T, empty bool) {
+	if !empty {
+		*field = value
+		settings.Sources[name] = source
+	}
+}
+
+// applyConfig applies the settings of the global config file.
+func (settings *Settings) applyConfig(config Config, source string) {
+	set(settings, "model", source, &settings.Model, config.ModelVersion, len(config.ModelVersion) == 0)
+}
+
+// applyProject applies the settings of the project config file.
+func (settings *Settings) applyProject(project ProjectConfig, source string) {
+	set(settings, "model", source, &settings.Model, project.Model, len(project.Model) == 0)
+	set(settings, "include", source, &settings.Include, project.Include, len(project.Include) == 0)
+	set(settings, "exclude", source, &settings.Exclude, project.Exclude, len(project.Exclude) == 0)
+	set(settings, "output", source, &settings.Output, project.Output, len(project.Output) == 0)
+	set(settings, "prompt-template", source, &settings.PromptTemplate, project.PromptTemplate, len(project.PromptTemplate) == 0)
+	set(settings, "sections", source, &settings.Sections, project.Sections, len(project.Sections) == 0)
+	set(settings, "max-file-size", source, &settings.MaxFileSize, int64(project.MaxFileSize), project.MaxFileSize == 0)
+	set(settings, "max-total-size", source, &settings.MaxTotalSize, int64(project.MaxTotalSize), project.MaxTotalSize == 0)
+	set(settings, "concurrency", source, &settings.Concurrency, project.Concurrency, project.Concurrency == 0)
+	set(settings, "follow-symlinks", source, &settings.FollowSymlinks, project.FollowSymlinks, !project.FollowSymlinks)
+	set(settings, "same-filesystem", source, &settings.SameFilesystem, project.SameFilesystem, !project.SameFilesystem)
+	set(settings, "secrets", source, &settings.Secrets, project.Secrets, len(project.Secrets) == 0)
+	set(settings, "secret-patterns", source, &settings.SecretPatterns, project.SecretPatterns, len(project.SecretPatterns) == 0)
+}
+
+// applyValues applies settings from string values, such as environment variables. list
+// settings are comma separated, sizes are parsed using parseSize, the concurrency is
+// parsed using parseConcurrency, booleans are parsed using strconv.ParseBool and the secrets
+// mode is checked using parseSecretsMode.
+//
+// Parameters:
+//   - lookup: Returns the value of a setting, and false if the setting is not set.
+//   - source: Returns the source of the setting with the given name.
+//
+// Returns:
+//   - error: An error if a size, the concurrency, a boolean or the secrets mode cannot be parsed.
+func (settings *Settings) applyValues(lookup func(name string) (string, bool), source func(name string) string) error {
+	for _, name := range settingNames {
+		value, ok := lookup(name)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "model":
+			set(settings, name, source(name), &settings.Model, value, false)
+		case "output":
+			set(settings, name, source(name), &settings.Output, value, false)
+		case "prompt-template":
+			set(settings, name, source(name), &settings.PromptTemplate, value, false)
+		case "include":
+			set(settings, name, source(name), &settings.Include, splitList(value), false)
+		case "exclude":
+			set(settings, name, source(name), &settings.Exclude, splitList(value), false)
+		case "sections":
+			set(settings, name, source(name), &settings.Sections, buildOutline(settings.Sections, splitList(value)), false)
+		case "max-file-size", "max-total-size":
+			size, err := parseSize(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %s from %s: %w", name, value, source(name), err)
+			}
+			if name == "max-file-size" {
+				set(settings, name, source(name), &settings.MaxFileSize, size, false)
+			} else {
+				set(settings, name, source(name), &settings.MaxTotalSize, size, false)
+			}
+		case "concurrency":
+			concurrency, err := parseConcurrency(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %s from %s: %w", name, value, source(name), err)
+			}
+			set(settings, name, source(name), &settings.Concurrency, concurrency, false)
+		case "follow-symlinks", "same-filesystem":
+			enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("invalid %s %s from %s: %w", name, value, source(name), err)
+			}
+			if name == "follow-symlinks" {
+				set(settings, name, source(name), &settings.FollowSymlinks, enabled, false)
+			} else {
+				set(settings, name, source(name), &settings.SameFilesystem, enabled, false)
+			}
+		case "secrets":
+			mode, err := parseSecretsMode(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %s from %s: %w", name, value, source(name), err)
+			}
+			set(settings, name, source(name), &settings.Secrets, mode, false)
+		}
+	}
+	return nil
+}
+
+// applyEnv applies the settings of the environment variables in settingEnvVars.
+func (settings *Settings) applyEnv(lookupEnv func(key string) (string, bool)) error {
+	return settings.applyValues(func(name string) (string, bool) {
+		key, ok := settingEnvVars[name]
+		if !ok {
+			return "", false
+		}
+		value, ok := lookupEnv(key)
+		return value, ok && len(value) > 0
+	}, func(name string) string {
+		return "env " + settingEnvVars[name]
+	})
+}
+
+// applyFlags applies the settings of the flags that are set on the provided command.
+// the sections setting is read from the repeatable --section flag.
+func (settings *Settings) applyFlags(cmd *cli.Command) error {
+	flags := map[string]string{"sections": "section"}
+	return settings.applyValues(func(name string) (string, bool) {
+		flag := name
+		if mapped, ok := flags[name]; ok {
+			flag = mapped
+		}
+		if !cmd.IsSet(flag) {
+			return "", false
+		}
+		switch name {
+		case "include", "exclude", "sections":
+			return strings.Join(cmd.StringSlice(flag), ","), true
+		case "follow-symlinks", "same-filesystem":
+			return strconv.FormatBool(cmd.Bool(flag)), true
+		default:
+			return cmd.String(flag), true
+		}
+	}, func(name string) string {
+		if mapped, ok := flags[name]; ok {
+			return "flag --" + mapped
+		}
+		return "flag --" + name
+	})
+}
+
+// resolveSettings resolves the effective settings for the target directory. The global
+// config file is optional, and is skipped if it cannot be loaded.
+//
+// Parameters:
+//   - cmd: The CLI command containing the settings flags.
+//   - target: The target directory of the project.
+//   - lookupEnv: Looks up environment variables, usually os.LookupEnv.
+//
+// Returns:
+//   - Settings: The resolved settings.
+//   - error: An error if the project config cannot be loaded or a setting is invalid.
+func resolveSettings(cmd *cli.Command, target string, lookupEnv func(key string) (string, bool)) (Settings, error) {
+	settings := newSettings()
+
+	cfgPath := cmd.String("config-path")
+	if config, err := loadConfig(cfgPath, profileFromCommand(cmd), ConfigOverrides{}); err == nil {
+		settings.applyConfig(config, cfgPath)
+	} else {
+		log.Debug(fmt.Sprintf("skipping global config when resolving settings: %+v", err))
+	}
+
+	project, err := loadProjectConfig(target)
+	if err != nil {
+		return settings, err
+	}
+	settings.applyProject(project, filepath.Join(target, ProjectConfigName))
+
+	if err := settings.applyEnv(lookupEnv); err != nil {
+		return settings, err
+	}
+	if err := settings.applyFlags(cmd); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+// Values returns the display value of each setting, keyed by the setting name.
+func (settings Settings) Values() map[string]string {
+	sections := []string{}
+	for _, section := range settings.Sections {
+		sections = append(sections, section.Name)
+	}
+	patterns := []string{}
+	for _, pattern := range settings.SecretPatterns {
+		patterns = append(patterns, pattern.Name)
+	}
+	return map[string]string{
+		"model":           settings.Model,
+		"include":         strings.Join(settings.Include, ", "),
+		"exclude":         strings.Join(settings.Exclude, ", "),
+		"output":          settings.Output,
+		"prompt-template": settings.PromptTemplate,
+		"sections":        strings.Join(sections, ", "),
+		"max-file-size":   formatSize(settings.MaxFileSize),
+		"max-total-size":  formatSize(settings.MaxTotalSize),
+		"concurrency":     strconv.Itoa(settings.Concurrency),
+		"follow-symlinks": strconv.FormatBool(settings.FollowSymlinks),
+		"same-filesystem": strconv.FormatBool(settings.SameFilesystem),
+		"secrets":         settings.Secrets,
+		"secret-patterns": strings.Join(patterns, ", "),
+	}
+}
+
+// OutputPath returns the path the README is written to.
+func (settings Settings) OutputPath(target string) string {
+	return resolvePath(target, settings.Output)
+}
+
+// DiscoveryOptions returns the options used to discover the files of the target directory.
+func (settings Settings) DiscoveryOptions() DiscoveryOptions {
+	return DiscoveryOptions{
+		FollowSymlinks: settings.FollowSymlinks,
+		SameFilesystem: settings.SameFilesystem,
+	}
+}
+
+// resolvePath resolves paths relative to the target directory. absolute paths are returned as is.
+func resolvePath(target, path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(target, path)
+}
+
+// parseConcurrency parses the number of files transferred at the same time, which must be at least 1.
+func parseConcurrency(value string) (int, error) {
+	concurrency, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if concurrency < 1 {
+		return 0, fmt.Errorf("concurrency must be at least 1")
+	}
+	return concurrency, nil
+}
+
+// parseSecretsMode parses the secrets mode, which must be redact, abort or off.
+func parseSecretsMode(value string) (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(value))
+	if !slices.Contains(secretsModes, mode) {
+		return "", fmt.Errorf("secrets mode must be one of %s", strings.Join(secretsModes, ", "))
+	}
+	return mode, nil
+}
+
+// splitList splits a comma separated list, removing any empty values.
+func splitList(value string) []string {
+	values := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// sizeUnits are the suffixes supported by parseSize and their multipliers.
+var sizeUnits = []struct {
+	Suffix     string
+	Multiplier int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size in bytes, with an optional KB, MB or GB suffix (e.g. 512KB).
+func parseSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	for _, unit := range sizeUnits {
+		if number, ok := strings.CutSuffix(value, unit.Suffix); ok {
+			size, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			return size * unit.Multiplier, err
+		}
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// formatSize formats a size in bytes using the largest unit that divides it exactly.
+func formatSize(size int64) string {
+	if size == 0 {
+		return "unlimited"
+	}
+	for _, unit := range sizeUnits {
+		if size%unit.Multiplier == 0 {
+			return fmt.Sprintf("%d%s", size/unit.Multiplier, unit.Suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", size)
+}
+
+// matchesPattern checks if a slash separated path relative to the target directory matches
+// a pattern. patterns ending with a slash match everything inside a directory, patterns
+// without a slash match the name of the file or any of its parent directories, and any
+// other pattern is matched against the whole path using matchPath. patterns starting with
+// a slash are always matched from the target directory.
+func matchesPattern(pattern, relative string) bool {
+	parts := strings.Split(relative, "/")
+	pattern, anchored := strings.CutPrefix(pattern, "/")
+
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		// only the parent directories of the file are matched
+		for i := range parts[:len(parts)-1] {
+			candidate := parts[i]
+			if anchored || strings.Contains(dir, "/") {
+				candidate = strings.Join(parts[:i+1], "/")
+			}
+			if matchPath(dir, candidate) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		for _, part := range parts {
+			if matched, _ := path.Match(pattern, part); matched {
+				return true
+			}
+		}
+		return false
+	}
+
+	return matchPath(pattern, relative)
+}
+
+// matchPath matches a slash separated path against a pattern segment by segment using
+// path.Match. a "**" segment matches any number of segments, including none, so
+// "src/**/*.go" matches both src/main.go and src/pkg/main.go. "**" within a segment,
+// e.g. "src/**.go", is matched as a single "*".
+func matchPath(pattern, relative string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(relative, "/"))
+}
+
+// matchSegments matches the segments of a path against the segments of a pattern.
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := range len(parts) + 1 {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	matched, _ := path.Match(pattern[0], parts[0])
+	return matched && matchSegments(pattern[1:], parts[1:])
+}
+
+// filterFiles applies the include, exclude and size settings to the discovered files.
+// patterns are matched against the path of each file on disk, not the name it is
+// uploaded as, and files larger than the maximum file size are skipped with a warning.
+//
+// Parameters:
+//   - target: The target directory of the project.
+//   - files: The discovered files, keyed by their upload name.
+//
+// Returns:
+//   - map[string]SourceFile: The files that should be uploaded.
+//   - error: An error if the total size of the files exceeds the maximum total size.
+func (settings Settings) filterFiles(target string, files map[string]SourceFile) (map[string]SourceFile, error) {
+	filtered := map[string]SourceFile{}
+
+	var total int64
+	for filename, file := range files {
+		// files such as .vue files are uploaded with a different extension
+		path := cmp.Or(file.Path, filename)
+		relative, err := filepath.Rel(target, path)
+		if err != nil {
+			relative = path
+		}
+		relative = filepath.ToSlash(relative)
+
+		if len(settings.Include) > 0 && !matchesAny(settings.Include, relative) {
+			log.Debug(fmt.Sprintf("skipping file %s: not included", relative))
+			continue
+		}
+		if matchesAny(settings.Exclude, relative) {
+			log.Debug(fmt.Sprintf("skipping file %s: excluded", relative))
+			continue
+		}
+
+		size := file.Size
+		if settings.MaxFileSize > 0 && size > settings.MaxFileSize {
+			log.Warn(fmt.Sprintf("skipping file %s: size %s exceeds maximum file size of %s", relative, formatSize(size), formatSize(settings.MaxFileSize)))
+			continue
+		}
+
+		total += size
+		filtered[filename] = file
+	}
+
+	if settings.MaxTotalSize > 0 && total > settings.MaxTotalSize {
+		return filtered, fmt.Errorf("total size of files %s exceeds maximum total size of %s", formatSize(total), formatSize(settings.MaxTotalSize))
+	}
+	return filtered, nil
+}
+
+// matchesAny checks if the path matches any of the patterns.
+func matchesAny(patterns []string, relative string) bool {
+	for _, pattern := range patterns {
+		if matchesPattern(pattern, relative) {
+			return true
+		}
+	}
+	return false
+}
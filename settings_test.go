@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/urfave/cli/v3"
+)
+
+// TestMatchesPattern tests matching file, directory and path patterns against relative paths.
+func TestMatchesPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		relative string
+		want     bool
+	}{
+		{pattern: "*.py", relative: "main.py", want: true},
+		{pattern: "*.py", relative: "nested/example.py", want: true},
+		{pattern: "*.py", relative: "main.go", want: false},
+		{pattern: "nested/", relative: "nested/example.py", want: true},
+		{pattern: "nested/", relative: "src/nested/example.py", want: true},
+		{pattern: "nested/", relative: "nested", want: false},
+		{pattern: "src/nested/", relative: "src/nested/example.py", want: true},
+		{pattern: "src/nested/", relative: "nested/example.py", want: false},
+		{pattern: "src/*.go", relative: "src/main.go", want: true},
+		{pattern: "src/*.go", relative: "src/pkg/main.go", want: false},
+		{pattern: "vendor", relative: "vendor/lib/lib.go", want: true},
+		{pattern: "/main.go", relative: "main.go", want: true},
+		{pattern: "/main.go", relative: "cmd/main.go", want: false},
+		{pattern: "/nested/", relative: "nested/example.py", want: true},
+		{pattern: "/nested/", relative: "src/nested/example.py", want: false},
+		{pattern: `/src/\[id\].go`, relative: "src/[id].go", want: true},
+		{pattern: "src/**/*.go", relative: "src/main.go", want: true},
+		{pattern: "src/**/*.go", relative: "src/pkg/main.go", want: true},
+		{pattern: "src/**/*.go", relative: "src/pkg/internal/deep/main.go", want: true},
+		{pattern: "src/**/*.go", relative: "cmd/src/main.go", want: false},
+		{pattern: "src/**/*.go", relative: "src/pkg/main.py", want: false},
+		{pattern: "**/testdata/*.json", relative: "testdata/case.json", want: true},
+		{pattern: "**/testdata/*.json", relative: "pkg/a/testdata/case.json", want: true},
+		{pattern: "**/testdata/*.json", relative: "pkg/a/testdata/nested/case.json", want: false},
+		{pattern: "docs/**", relative: "docs/guide/intro.md", want: true},
+		{pattern: "docs/**", relative: "src/docs/intro.md", want: false},
+		{pattern: "/**/generated/", relative: "api/v1/generated/types.go", want: true},
+		{pattern: "/**/generated/", relative: "generated.go", want: false},
+		{pattern: "src/**.go", relative: "src/pkg/main.go", want: false},
+	}
+
+	for _, test := range tests {
+		if got := matchesPattern(test.pattern, test.relative); got != test.want {
+			t.Errorf("%s %s got: %v, want: %v", test.pattern, test.relative, got, test.want)
+		}
+	}
+}
+
+// TestParseSize tests parsing sizes with and without unit suffixes.
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"100":    100,
+		"512KB":  512 << 10,
+		"2 mb":   2 << 20,
+		"1GB":    1 << 30,
+		"1024B":  1024,
+		" 10KB ": 10 << 10,
+	}
+	for value, want := range tests {
+		got, err := parseSize(value)
+		if err != nil {
+			t.Errorf("error parsing %q: %v", value, err)
+		} else if got != want {
+			t.Errorf("%q got: %d, want: %d", value, got, want)
+		}
+	}
+
+	if _, err := parseSize("ten MB"); err == nil {
+		t.Error("expected error parsing invalid size")
+	}
+
+	if got := formatSize(2 << 20); got != "2MB" {
+		t.Errorf("got: %s, want: %s", got, "2MB")
+	}
+}
+
+// TestResolveSettings tests that settings are resolved from the global config, the
+// project config, environment variables and flags in order of precedence, and that
+// the source of each setting is recorded.
+func TestResolveSettings(t *testing.T) {
+	target := t.TempDir()
+	project := "model: project-model\noutput: docs/README.md\nexclude: [tests/]\nmaxFileSize: 1KB\nsameFilesystem: true\nsections:\n  - name: Usage\n    guidance: usage guidance\n"
+	if err := os.WriteFile(filepath.Join(target, ProjectConfigName), []byte(project), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"GOREADME_OUTPUT":          "DOCS.md",
+		"GOREADME_MAX_FILE_SIZE":   "2KB",
+		"GOREADME_FOLLOW_SYMLINKS": "true",
+		"GOREADME_SECRETS":         "abort",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	var settings Settings
+	cmd := &cli.Command{
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "config-path", Value: "tests/config.json"},
+		}, settingsFlags()...),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			var err error
+			settings, err = resolveSettings(cmd, target, lookupEnv)
+			return err
+		},
+	}
+	if err := cmd.Run(context.Background(), []string{"goreadme", "--section", "usage", "--section", "License", "--max-file-size", "3KB"}); err != nil {
+		t.Fatal(err)
+	}
+
+	projectSource := filepath.Join(target, ProjectConfigName)
+	want := map[string]string{
+		"model":           projectSource,
+		"include":         SettingSourceDefault,
+		"exclude":         projectSource,
+		"output":          "env GOREADME_OUTPUT",
+		"prompt-template": SettingSourceDefault,
+		"sections":        "flag --section",
+		"max-file-size":   "flag --max-file-size",
+		"max-total-size":  SettingSourceDefault,
+		"follow-symlinks": "env GOREADME_FOLLOW_SYMLINKS",
+		"same-filesystem": projectSource,
+		"secrets":         "env GOREADME_SECRETS",
+	}
+	for name, source := range want {
+		if settings.Sources[name] != source {
+			t.Errorf("%s got: %s, want: %s", name, settings.Sources[name], source)
+		}
+	}
+
+	if settings.Model != "project-model" || settings.Output != "DOCS.md" || settings.MaxFileSize != 3<<10 || !settings.FollowSymlinks || !settings.SameFilesystem || settings.Secrets != SecretsModeAbort {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+
+	// sections passed as flags keep the guidance of the project config
+	if len(settings.Sections) != 2 || settings.Sections[0].Guidance != "usage guidance" || settings.Sections[1].Name != "License" {
+		t.Errorf("unexpected sections %+v", settings.Sections)
+	}
+
+	// the model of the global config is used if the project does not set a model
+	global := newSettings()
+	global.applyConfig(Config{ModelVersion: "test-model"}, "tests/config.json")
+	global.applyProject(ProjectConfig{}, projectSource)
+	if global.Model != "test-model" || global.Sources["model"] != "tests/config.json" {
+		t.Errorf("got: %s (%s), want: %s (%s)", global.Model, global.Sources["model"], "test-model", "tests/config.json")
+	}
+}
+
+// TestFilterFiles tests that the include, exclude and size settings are applied to the discovered files.
+func TestFilterFiles(t *testing.T) {
+	files := func() map[string]SourceFile {
+		return map[string]SourceFile{
+			"target/main.go":         {Path: "target/main.go", Size: 12},
+			"target/cmd/cli.go":      {Path: "target/cmd/cli.go", Size: 11},
+			"target/tests/helper.py": {Path: "target/tests/helper.py", Size: 15},
+			"target/large.py":        {Path: "target/large.py", Size: 2048},
+			"target/src/App.vue.txt": {Path: "target/src/App.vue", Size: 10},
+		}
+	}
+
+	settings := newSettings()
+	settings.Include = []string{"*.go", "*.py"}
+	settings.Exclude = []string{"tests/"}
+	settings.MaxFileSize = 1024
+
+	filtered, err := settings.filterFiles("target", files())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := relativePaths("target", filtered)
+	if !slices.Equal(got, []string{"cmd/cli.go", "main.go"}) {
+		t.Errorf("got: %v, want: %v", got, []string{"cmd/cli.go", "main.go"})
+	}
+
+	// patterns match the path on disk rather than the renamed upload name
+	for _, pattern := range []string{"*.vue", "/src/App.vue", "src/"} {
+		settings = newSettings()
+		settings.Exclude = []string{pattern}
+		filtered, err = settings.filterFiles("target", files())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := filtered["target/src/App.vue.txt"]; ok {
+			t.Errorf("expected %s to exclude src/App.vue", pattern)
+		}
+	}
+	settings = newSettings()
+	settings.Include = []string{"*.vue"}
+	filtered, err = settings.filterFiles("target", files())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := relativePaths("target", filtered); !slices.Equal(got, []string{"src/App.vue.txt"}) {
+		t.Errorf("got: %v, want: %v", got, []string{"src/App.vue.txt"})
+	}
+
+	settings = newSettings()
+	settings.MaxTotalSize = 1024
+	if _, err := settings.filterFiles("target", files()); err == nil {
+		t.Error("expected error when total size exceeds the maximum")
+	}
+}
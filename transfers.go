@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultConcurrency is the number of files uploaded or deleted at the same time.
+const DefaultConcurrency = 5
+
+// TransferResult is the result of uploading or deleting a single file.
+type TransferResult struct {
+	// Name is the filename of uploaded files, or the file ID of deleted files
+	Name   string
+	FileId string
+	Err    error
+}
+
+// TransferResults are the results of uploading or deleting files, keyed by the filename
+// of uploaded files or the file ID of deleted files.
+type TransferResults map[string]TransferResult
+
+// FileIds returns the IDs of the files that were transferred successfully, sorted by name.
+func (results TransferResults) FileIds() []string {
+	fileIds := []string{}
+	for _, name := range results.names() {
+		if result := results[name]; result.Err == nil {
+			fileIds = append(fileIds, result.FileId)
+		}
+	}
+	return fileIds
+}
+
+// Failed returns the results of the files that could not be transferred, sorted by name.
+func (results TransferResults) Failed() []TransferResult {
+	failed := []TransferResult{}
+	for _, name := range results.names() {
+		if result := results[name]; result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Err returns an error containing the error of every file that could not be
+// transferred, or nil if every file was transferred.
+func (results TransferResults) Err() error {
+	errs := []error{}
+	for _, result := range results.Failed() {
+		errs = append(errs, fmt.Errorf("%s: %w", result.Name, result.Err))
+	}
+	return errors.Join(errs...)
+}
+
+// names returns the names of the results in sorted order.
+func (results TransferResults) names() []string {
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// TransferProgress is called once each file has been transferred, with the number of files
+// transferred so far. calls are never made concurrently, so callbacks do not need locking.
+type TransferProgress func(result TransferResult, done, total int)
+
+// transferFiles runs the transfer of each named file using an errgroup, limiting the number
+// of transfers running at the same time to concurrency. Every file is attempted even if other
+// files fail, so a failure never leaves the result of a file unknown. Files that have not
+// started when the context is cancelled are recorded with the error of the context.
+//
+// Parameters:
+//   - ctx: The context of the transfers.
+//   - names: The names of the files to transfer.
+//   - concurrency: The maximum number of transfers running at the same time.
+//   - transfer: Transfers a single file, returning the file ID of the transferred file.
+//   - progress: Called after each file is transferred, or nil.
+//
+// Returns:
+//   - TransferResults: The result of every file, keyed by name.
+func transferFiles(ctx context.Context, names []string, concurrency int, transfer func(name string) (string, error), progress TransferProgress) TransferResults {
+	results := TransferResults{}
+	var mu sync.Mutex
+
+	group := errgroup.Group{}
+	group.SetLimit(max(concurrency, 1))
+
+	for _, name := range names {
+		group.Go(func() error {
+			result := TransferResult{Name: name}
+			if err := ctx.Err(); err != nil {
+				result.Err = err
+			} else {
+				result.FileId, result.Err = transfer(name)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			if progress != nil {
+				progress(result, len(results), len(names))
+			}
+			// errors are recorded in the results rather than returned, so that
+			// the remaining files are still transferred
+			return nil
+		})
+	}
+	group.Wait()
+
+	return results
+}
+
+// uploadFiles uploads the files concurrently, keyed by the filename the file is uploaded with.
+// Every file is attempted, so callers should delete the files that were uploaded if any
+// file failed and the upload is abandoned. Readers that implement io.Closer are closed once
+// every file has been attempted, including readers of files that were never uploaded.
+//
+// Parameters:
+//   - ctx: The context of the uploads.
+//   - client: The client used to upload the files.
+//   - files: The contents of the files to upload, keyed by filename.
+//   - concurrency: The maximum number of files uploaded at the same time.
+//   - progress: Called after each file is uploaded, or nil.
+//
+// Returns:
+//   - TransferResults: The result of every file, keyed by filename.
+func uploadFiles(ctx context.Context, client ChatGPTService, files map[string]io.Reader, concurrency int, progress TransferProgress) TransferResults {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	results := transferFiles(ctx, names, concurrency, func(name string) (string, error) {
+		return client.UploadFile(name, files[name])
+	}, progress)
+
+	for _, file := range files {
+		if closer, ok := file.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	return results
+}
+
+// deleteFiles deletes the files with the given IDs concurrently. Every file is attempted
+// even if other files cannot be deleted.
+//
+// Parameters:
+//   - ctx: The context of the deletions.
+//   - client: The client used to delete the files.
+//   - fileIds: The IDs of the files to delete.
+//   - concurrency: The maximum number of files deleted at the same time.
+//   - progress: Called after each file is deleted, or nil.
+//
+// Returns:
+//   - TransferResults: The result of every file, keyed by file ID.
+func deleteFiles(ctx context.Context, client ChatGPTService, fileIds []string, concurrency int, progress TransferProgress) TransferResults {
+	return transferFiles(ctx, fileIds, concurrency, func(id string) (string, error) {
+		return id, client.DeleteFile(id)
+	}, progress)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeFileService implements the file methods of ChatGPTService, failing the files in
+// failures and recording the largest number of requests running at the same time.
+type fakeFileService struct {
+	ChatGPTService
+	failures map[string]bool
+	running  atomic.Int32
+	peak     atomic.Int32
+	mu       sync.Mutex
+	deleted  []string
+}
+
+// track records a request as running until the returned function is called.
+func (service *fakeFileService) track() func() {
+	running := service.running.Add(1)
+	for {
+		peak := service.peak.Load()
+		if running <= peak || service.peak.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return func() { service.running.Add(-1) }
+}
+
+func (service *fakeFileService) UploadFile(filename string, content io.Reader) (string, error) {
+	defer service.track()()
+	if _, err := io.ReadAll(content); err != nil {
+		return "", err
+	}
+	if service.failures[filename] {
+		return "", errors.New("upload failed")
+	}
+	return "file-" + filename, nil
+}
+
+func (service *fakeFileService) DeleteFile(id string) error {
+	defer service.track()()
+	if service.failures[id] {
+		return errors.New("delete failed")
+	}
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	service.deleted = append(service.deleted, id)
+	return nil
+}
+
+// TestUploadFiles tests that every file is uploaded with results keyed by filename, that
+// failed files do not stop the other files, and that the concurrency limit is respected.
+func TestUploadFiles(t *testing.T) {
+	files := map[string]io.Reader{}
+	for i := 0; i < 20; i++ {
+		files[fmt.Sprintf("file%02d.txt", i)] = strings.NewReader("content")
+	}
+
+	service := &fakeFileService{failures: map[string]bool{"file03.txt": true, "file11.txt": true}}
+
+	progress := []int{}
+	results := uploadFiles(context.Background(), service, files, 3, func(result TransferResult, done, total int) {
+		if total != len(files) {
+			t.Errorf("got: %d, want: %d", total, len(files))
+		}
+		progress = append(progress, done)
+	})
+
+	if len(results) != len(files) {
+		t.Fatalf("got: %d results, want: %d", len(results), len(files))
+	}
+	if results["file05.txt"].FileId != "file-file05.txt" {
+		t.Errorf("got: %s, want: %s", results["file05.txt"].FileId, "file-file05.txt")
+	}
+	if len(results.FileIds()) != 18 {
+		t.Errorf("got: %d file ids, want: %d", len(results.FileIds()), 18)
+	}
+
+	failed := []string{}
+	for _, result := range results.Failed() {
+		failed = append(failed, result.Name)
+	}
+	if !slices.Equal(failed, []string{"file03.txt", "file11.txt"}) {
+		t.Errorf("got: %v, want: %v", failed, []string{"file03.txt", "file11.txt"})
+	}
+	if err := results.Err(); err == nil || !strings.Contains(err.Error(), "file03.txt") {
+		t.Errorf("expected error naming the failed files, got: %v", err)
+	}
+
+	if peak := service.peak.Load(); peak > 3 {
+		t.Errorf("got: %d concurrent uploads, want: at most %d", peak, 3)
+	}
+
+	want := []int{}
+	for i := 1; i <= len(files); i++ {
+		want = append(want, i)
+	}
+	if !slices.Equal(progress, want) {
+		t.Errorf("got: %v, want: %v", progress, want)
+	}
+}
+
+// TestDeleteFiles tests that every file is deleted with results keyed by file ID, and
+// that files are not deleted once the context has been cancelled.
+func TestDeleteFiles(t *testing.T) {
+	service := &fakeFileService{failures: map[string]bool{"file-b": true}}
+
+	results := deleteFiles(context.Background(), service, []string{"file-a", "file-b", "file-c"}, 2, nil)
+	if !slices.Equal(results.FileIds(), []string{"file-a", "file-c"}) {
+		t.Errorf("got: %v, want: %v", results.FileIds(), []string{"file-a", "file-c"})
+	}
+	if results["file-b"].Err == nil {
+		t.Error("expected error deleting file-b")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	service.deleted = nil
+	results = deleteFiles(ctx, service, []string{"file-a", "file-c"}, 2, nil)
+	if len(service.deleted) != 0 || !errors.Is(results["file-a"].Err, context.Canceled) {
+		t.Errorf("got: deleted %v, error %v, want: no files deleted after cancellation", service.deleted, results["file-a"].Err)
+	}
+}
@@ -12,7 +12,60 @@ type ChatGPTCredentials struct {
 }
 
 type VectorStore struct {
-	Id string `json:"id"`
+	Id           string                   `json:"id"`
+	Name         string                   `json:"name"`
+	Status       string                   `json:"status"`
+	UsageBytes   int64                    `json:"usage_bytes"`
+	FileCounts   FileCounts               `json:"file_counts"`
+	ExpiresAfter *VectorStoreExpiresAfter `json:"expires_after,omitempty"`
+	ExpiresAt    int64                    `json:"expires_at"`
+	Metadata     map[string]string        `json:"metadata"`
+	CreatedAt    int64                    `json:"created_at"`
+}
+
+// VectorStoreExpiresAfter is the expiration policy of a vector store. the vector store
+// expires the given number of days after the anchor timestamp.
+type VectorStoreExpiresAfter struct {
+	Anchor string `json:"anchor"`
+	Days   int    `json:"days"`
+}
+
+// FileCounts contains the number of files of a vector store or file batch in each status.
+type FileCounts struct {
+	InProgress int `json:"in_progress"`
+	Completed  int `json:"completed"`
+	Failed     int `json:"failed"`
+	Cancelled  int `json:"cancelled"`
+	Total      int `json:"total"`
+}
+
+// VectorStoreFile is a file attached to a vector store.
+type VectorStoreFile struct {
+	Id            string `json:"id"`
+	VectorStoreId string `json:"vector_store_id"`
+	Status        string `json:"status"`
+	UsageBytes    int64  `json:"usage_bytes"`
+	CreatedAt     int64  `json:"created_at"`
+	LastError     *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"last_error"`
+}
+
+// VectorStoreFileBatch is a batch of files added to a vector store at once.
+type VectorStoreFileBatch struct {
+	Id            string     `json:"id"`
+	VectorStoreId string     `json:"vector_store_id"`
+	Status        string     `json:"status"`
+	FileCounts    FileCounts `json:"file_counts"`
+}
+
+type File struct {
+	Id        string `json:"id"`
+	Filename  string `json:"filename"`
+	Bytes     int64  `json:"bytes"`
+	Purpose   string `json:"purpose"`
+	CreatedAt int64  `json:"created_at"`
 }
 
 type AssistantToolResources struct {
@@ -23,7 +76,22 @@ type AssistantToolResources struct {
 
 type Assistant struct {
 	Id            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Instructions  string                 `json:"instructions"`
+	Model         string                 `json:"model"`
+	Tools         []Tool                 `json:"tools"`
 	ToolResources AssistantToolResources `json:"tool_resources"`
+	Metadata      map[string]string      `json:"metadata"`
+	CreatedAt     int64                  `json:"created_at"`
+}
+
+// AssistantUpdate contains the fields of an assistant to modify. fields that are not
+// set are left unchanged.
+type AssistantUpdate struct {
+	Model         string                  `json:"model,omitempty"`
+	Instructions  *string                 `json:"instructions,omitempty"`
+	ToolResources *AssistantToolResources `json:"tool_resources,omitempty"`
 }
 
 type Model struct {
@@ -49,14 +117,30 @@ type ThreadMessage struct {
 	Attachments []FileAttachment `json:"attachments"`
 }
 
+type TextAnnotation struct {
+	Type         string `json:"type"`
+	Text         string `json:"text"`
+	StartIndex   int    `json:"start_index"`
+	EndIndex     int    `json:"end_index"`
+	FileCitation struct {
+		FileId string `json:"file_id"`
+		Quote  string `json:"quote"`
+	} `json:"file_citation"`
+}
+
 type ThreadMessageContent struct {
 	Type string `json:"type"`
 	Text struct {
-		Value string `json:"value"`
+		Value       string           `json:"value"`
+		Annotations []TextAnnotation `json:"annotations"`
 	} `json:"text"`
 }
 
 type ThreadMessageResponse struct {
+	Id          string                 `json:"id"`
+	CreatedAt   int64                  `json:"created_at"`
+	AssistantId string                 `json:"assistant_id"`
+	RunId       string                 `json:"run_id"`
 	Role        string                 `json:"role"`
 	Content     []ThreadMessageContent `json:"content"`
 	Attachments []FileAttachment       `json:"attachments"`
@@ -67,3 +151,25 @@ type ThreadRun struct {
 	ThreadId string `json:"thread_id"`
 	Status   string `json:"status"`
 }
+
+type RunStep struct {
+	Id        string `json:"id"`
+	RunId     string `json:"run_id"`
+	Type      string `json:"type"`
+	Status    string `json:"status"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+type ListOptions struct {
+	After  string
+	Before string
+	Limit  int
+	Order  string
+}
+
+type ListResponse[T any] struct {
+	Data    []T    `json:"data"`
+	FirstId string `json:"first_id"`
+	LastId  string `json:"last_id"`
+	HasMore bool   `json:"has_more"`
+}
@@ -2,18 +2,16 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/sync/semaphore"
 )
 
 // configureLogging takes a log level in string format
@@ -68,78 +66,6 @@ func getCliInput(reader *bufio.Reader, prompt string, action func(value string)
 	}
 }
 
-// uploadFiles uploads multiple files concurrently using the provided ChatGPTAssistantClient.
-// It limits the number of concurrent uploads using a semaphore with a weight of 5.
-//
-// Parameters:
-//   - client: A pointer to a ChatGPTAssistantClient used to upload the files.
-//   - files: A slice of io.Reader representing the files to be uploaded.
-//
-// Returns:
-//   - A slice of strings containing the file IDs of the successfully uploaded files.
-//   - A slice of errors containing any errors that occurred during the upload process.
-func uploadFiles(client *ChatGPTAssistantClient, files map[string]io.Reader) ([]string, []error) {
-	errors := []error{}
-	fileIds := []string{}
-
-	semaphore := semaphore.NewWeighted(5)
-
-	var wg sync.WaitGroup
-	wg.Add(len(files))
-
-	for filename, filecontent := range files {
-
-		if err := semaphore.Acquire(context.Background(), 1); err != nil {
-			errors = append(errors, err)
-			continue
-		}
-
-		go func(name string, content io.Reader) {
-			defer wg.Done()
-			defer semaphore.Release(1)
-
-			fileId, err := client.UploadFile(name, content)
-			if err != nil {
-				errors = append(errors, err)
-			} else {
-				fileIds = append(fileIds, fileId)
-			}
-		}(filename, filecontent)
-	}
-	wg.Wait()
-
-	return fileIds, errors
-}
-
-func deleteFiles(client *ChatGPTAssistantClient, fileIds []string) []error {
-	errors := []error{}
-
-	semaphore := semaphore.NewWeighted(5)
-
-	var wg sync.WaitGroup
-	wg.Add(len(fileIds))
-
-	for _, fid := range fileIds {
-
-		if err := semaphore.Acquire(context.Background(), 1); err != nil {
-			errors = append(errors, err)
-			continue
-		}
-
-		go func(id string) {
-			defer wg.Done()
-			defer semaphore.Release(1)
-
-			if err := client.DeleteFile(id); err != nil {
-				errors = append(errors, err)
-			}
-		}(fid)
-	}
-	wg.Wait()
-
-	return errors
-}
-
 // isAllowedFile checks if the given filename has an allowed extension.
 // It returns true if the filename ends with one of the allowed extensions, otherwise false.
 func isAllowedFile(filename string) (string, bool) {
@@ -202,96 +128,243 @@ func isAllowedFile(filename string) (string, bool) {
 	return filename, false
 }
 
-// getFilesToUpload reads all files in the specified directory and returns a slice of io.Reader
-// containing the contents of each file.
+// SourceFile is a source code file discovered in the target directory. The contents
+// of the file are not read during discovery, and are only streamed when the file is uploaded.
+type SourceFile struct {
+	// Path is the path of the file on disk
+	Path string
+	// Size is the size of the file in bytes
+	Size int64
+	// Open opens the contents of the file
+	Open func() (io.ReadCloser, error)
+}
+
+// newSourceFile creates a source file that is opened from disk.
+func newSourceFile(path string, size int64) SourceFile {
+	return SourceFile{
+		Path: path,
+		Size: size,
+		Open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+	}
+}
+
+// getFilesToUpload discovers the files in the specified directory that can be uploaded.
+// Only the path and size of each file are read, so memory does not grow with the size of
+// the files. Paths that cannot be read, symlinks that are not followed and paths on other
+// filesystems are skipped and returned, so they can be reported once the run has finished.
 //
 // Parameters:
 //   - path: The directory path where the files are located.
+//   - options: Controls whether symlinks are followed and other filesystems are walked.
 //
 // Returns:
-//   - []io.Reader: A slice of io.Reader containing the contents of each file.
-//
-// Note:
-//   - If there is an error opening or reading a file, the function will silently ignore the error
-//     and continue processing the next file.
-func getFilesToUpload(path string) (map[string]io.Reader, error) {
-	files := map[string]io.Reader{}
-
-	err := filepath.WalkDir(path, func(f string, d os.DirEntry, e error) error {
-		// if entry is a directory, skip
-		if d.IsDir() {
-			return nil
-		}
-		// if entry is not in the allowed file types, skip
-		mappedFilename, allowed := isAllowedFile(f)
-		if !allowed {
-			return nil
-		}
-		log.Debug(fmt.Sprintf("adding file %s", f))
+//   - map[string]SourceFile: The discovered files, keyed by the name they are uploaded with.
+//   - []SkippedPath: The paths that were skipped, in the order they were walked.
+//   - error: An error if the directory itself cannot be read.
+func getFilesToUpload(path string, options DiscoveryOptions) (map[string]SourceFile, []SkippedPath, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		file, err := os.OpenFile(f, os.O_RDONLY, 0644)
-		if err != nil {
-			log.Warn(fmt.Sprintf("error opening file %s: %+v", f, err))
-			return nil
+	walker := fileWalker{
+		options: options,
+		files:   map[string]SourceFile{},
+		skipped: []SkippedPath{},
+	}
+	if options.SameFilesystem {
+		if device, ok := fileDevice(info); ok {
+			walker.device = device
+		} else {
+			log.Warn("filesystems cannot be detected on this platform, walking every filesystem")
+			walker.options.SameFilesystem = false
 		}
-		defer file.Close()
+	}
 
-		content, _ := io.ReadAll(file)
-		buffer := bytes.NewBuffer(content)
-		files[mappedFilename] = buffer
-		return nil
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	walker.walkEntries(path, entries, []os.FileInfo{info})
+	return walker.files, walker.skipped, nil
+}
+
+// streamReader is an io.ReadCloser that streams the output of a writer function through an
+// io.Pipe. The writer is only started when the reader is first used, and closing the reader
+// stops the writer.
+type streamReader struct {
+	once   sync.Once
+	write  func(writer *io.PipeWriter)
+	reader *io.PipeReader
+}
+
+// start starts the writer if it has not been started.
+func (stream *streamReader) start() {
+	stream.once.Do(func() {
+		reader, writer := io.Pipe()
+		stream.reader = reader
+		go stream.write(writer)
 	})
+}
 
-	return files, err
+func (stream *streamReader) Read(p []byte) (int, error) {
+	stream.start()
+	return stream.reader.Read(p)
 }
 
-// combineFiles takes a map of filenames to io.Reader objects and combines their contents
-// into a single io.Reader. Each file's content is prefixed with a header containing the
-// filename. The combined content is separated by two newlines.
+func (stream *streamReader) Close() error {
+	stream.start()
+	return stream.reader.Close()
+}
+
+// combineFiles combines the contents of the files into a single stream. Each file's content
+// is prefixed with a header containing the filename and followed by a footer, and the
+// combined content is separated by two newlines. The files are opened one at a time as the
+// stream is read, so only a single buffer is held in memory regardless of the size of the files.
 //
 // Parameters:
-//   - files: A map where the key is the filename (string) and the value is an io.Reader
-//     containing the file's content.
+//   - files: A map where the key is the filename (string) and the value is the source file.
 //
 // Returns:
-//   - An io.Reader containing the combined content of all files, with each file's content
-//     prefixed by a header with the filename and separated by two newlines.
-func combineFiles(files map[string]io.Reader) io.Reader {
-	var combinedFiles bytes.Buffer
-
-	for path, content := range files {
-		combinedFiles.WriteString(fmt.Sprintf("### FILE START %s\n\n", path))
-		if _, err := combinedFiles.ReadFrom(content); err != nil {
-			log.Warn(fmt.Sprintf("error reading content from file %s: %+v", path, err))
-			return &combinedFiles
-		}
+//   - An io.ReadCloser streaming the combined content of all files. Files are combined in
+//     order of their path so the output is deterministic. Reading fails with the error of
+//     any file that cannot be read.
+func combineFiles(files map[string]SourceFile) io.ReadCloser {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	return &streamReader{
+		write: func(writer *io.PipeWriter) {
+			for _, path := range paths {
+				if err := writeSourceBlock(writer, path, files[path]); err != nil {
+					log.Warn(fmt.Sprintf("error reading content from file %s: %+v", path, err))
+					writer.CloseWithError(err)
+					return
+				}
+			}
+			writer.Close()
+		},
+	}
+}
 
-		combinedFiles.WriteString(fmt.Sprintf("\n\n### FILE END %s\n\n", path))
+// writeSourceBlock writes the contents of a single file to the writer, delimited by the
+// FILE START and FILE END markers.
+func writeSourceBlock(writer io.Writer, path string, file SourceFile) error {
+	content, err := file.Open()
+	if err != nil {
+		return err
 	}
+	defer content.Close()
 
-	return &combinedFiles
+	if _, err := fmt.Fprintf(writer, "### FILE START %s\n\n", path); err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, content); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer, "\n\n### FILE END %s\n\n", path)
+	return err
 }
 
-// groupFilesByExtension groups a map of file names and their corresponding io.Reader content
+// groupFilesByExtension groups a map of file names and their corresponding source files
 // by their file extensions. It returns a nested map where the keys are file extensions and
-// the values are maps of file names and their io.Reader content.
+// the values are maps of file names and their source files.
 //
 // Parameters:
-//   - files: A map where the keys are file names and the values are io.Reader instances
-//     representing the content of the files.
+//   - files: A map where the keys are file names and the values are the source files.
 //
 // Returns:
 //   - A nested map where the keys are file extensions (including the dot, e.g., ".txt")
-//     and the values are maps of file names and their corresponding io.Reader content.
-func groupFilesByExtension(files map[string]io.Reader) map[string]map[string]io.Reader {
-	groupedFiles := make(map[string]map[string]io.Reader)
+//     and the values are maps of file names and their corresponding source files.
+func groupFilesByExtension(files map[string]SourceFile) map[string]map[string]SourceFile {
+	groupedFiles := make(map[string]map[string]SourceFile)
 
-	for filename, content := range files {
+	for filename, file := range files {
 		ext := filepath.Ext(filename)
 		if _, ok := groupedFiles[ext]; !ok {
-			groupedFiles[ext] = make(map[string]io.Reader)
+			groupedFiles[ext] = make(map[string]SourceFile)
 		}
-		groupedFiles[ext][filename] = content
+		groupedFiles[ext][filename] = file
 	}
 	return groupedFiles
 }
+
+// latestAssistantMessage selects the most recently created message with the
+// assistant role from a list of thread messages. The order of the provided
+// messages does not matter. false is returned if the thread does not contain
+// any assistant messages.
+func latestAssistantMessage(messages []ThreadMessageResponse) (ThreadMessageResponse, bool) {
+	var latest ThreadMessageResponse
+	found := false
+
+	for _, message := range messages {
+		if message.Role != "assistant" {
+			continue
+		}
+		if !found || message.CreatedAt > latest.CreatedAt {
+			latest = message
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// requestRevision asks the assistant to revise its previous output by adding a new
+// message to the thread and running the assistant again. The revised output is
+// extracted from the new run and returned.
+//
+// Parameters:
+//   - client: The client used to communicate with the assistant.
+//   - assistantId: The ID of the assistant used for the revision run.
+//   - threadId: The ID of the thread containing the previous output.
+//   - instructions: The message describing the changes that are required.
+//   - resolver: The resolver used for file citations in the revised output.
+//
+// Returns:
+//   - string: The revised output of the assistant.
+//   - error: An error if the revision run fails or does not produce any output.
+func requestRevision(client *ChatGPTAssistantClient, assistantId, threadId, instructions string, resolver *CitationResolver) (string, error) {
+	message := ThreadMessage{
+		Role:        "user",
+		Content:     instructions,
+		Attachments: []FileAttachment{},
+	}
+	if err := client.CreateMessage(threadId, message); err != nil {
+		return "", err
+	}
+
+	run, err := client.CreateRun(threadId, assistantId)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.WaitForRunCompletion(threadId, run.Id)
+	if err != nil {
+		return "", err
+	} else if result.Status != "completed" {
+		return "", fmt.Errorf("revision run %s finished with status %s", run.Id, result.Status)
+	}
+
+	messages, err := client.GetThreadMessages(threadId)
+	if err != nil {
+		return "", err
+	}
+	return extractAssistantOutput(messages, threadId, run.Id, resolver)
+}
+
+// maskSecret hides all but the last four characters of a secret. references
+// to secrets, such as env:OPENAI_API_KEY, are not hidden.
+func maskSecret(secret string) string {
+	if isSecretReference(secret) {
+		return secret
+	}
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", 8) + secret[len(secret)-4:]
+}
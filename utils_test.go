@@ -2,7 +2,7 @@ package main
 
 import (
 	"bufio"
-	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -192,7 +192,7 @@ func TestIsAllowedFile(t *testing.T) {
 func TestGetFilesToUpload(t *testing.T) {
 	basedir := "tests/src"
 
-	toUpload, err := getFilesToUpload(basedir)
+	toUpload, skipped, err := getFilesToUpload(basedir, DiscoveryOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -200,10 +200,13 @@ func TestGetFilesToUpload(t *testing.T) {
 	if len(toUpload) != 3 {
 		t.Errorf("got: %d, want: %d", len(toUpload), 3)
 	}
+	if len(skipped) != 0 {
+		t.Errorf("got: %v, want: %v", skipped, []SkippedPath{})
+	}
 }
 
-// TestCombineFiles tests the combineFiles function by opening a set of predefined
-// file paths, reading their contents, and combining them into a single reader.
+// TestCombineFiles tests the combineFiles function by discovering a set of predefined
+// file paths and streaming their contents into a single reader.
 // It then compares the combined contents to an expected string to ensure the
 // combineFiles function works correctly. If the combined contents do not match
 // the expected string, the test fails with a descriptive error message.
@@ -214,23 +217,17 @@ func TestCombineFiles(t *testing.T) {
 		"tests/src/nested/example.py",
 	}
 
-	files := map[string]io.Reader{}
+	files := map[string]SourceFile{}
 	for _, p := range paths {
-		file, err := os.Open(p)
-		if err != nil {
-			t.Fatalf("error opening test file %s: %+v", p, err)
-		}
-		defer file.Close()
-
-		contents, err := io.ReadAll(file)
+		info, err := os.Stat(p)
 		if err != nil {
 			t.Fatalf("error reading test file %s: %+v", p, err)
 		}
-		buffer := bytes.NewBuffer(contents)
-		files[p] = buffer
+		files[p] = newSourceFile(p, info.Size())
 	}
 
 	combined := combineFiles(files)
+	defer combined.Close()
 	bytesContent, err := io.ReadAll(combined)
 	if err != nil {
 		t.Fatalf("error reading combined file: %+v", err)
@@ -267,22 +264,35 @@ def some_example_function(bar: str):
 	}
 }
 
+// TestCombineFilesError tests that reading the combined stream fails with the error
+// of a file that cannot be opened, rather than silently omitting the file.
+func TestCombineFilesError(t *testing.T) {
+	files := map[string]SourceFile{
+		"tests/src/main.py":    newSourceFile("tests/src/main.py", 0),
+		"tests/src/missing.py": newSourceFile("tests/src/missing.py", 0),
+	}
+
+	combined := combineFiles(files)
+	defer combined.Close()
+	if _, err := io.ReadAll(combined); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got: %v, want: %v", err, os.ErrNotExist)
+	}
+}
+
 // TestGroupFilesByExtension tests the groupFilesByExtension function to ensure
 // that it correctly groups files by their extensions. It creates a map of file
-// names to io.Reader objects, calls the groupFilesByExtension function, and
+// names to source files, calls the groupFilesByExtension function, and
 // verifies that the files are grouped as expected. The test checks that the
 // number of groupings matches the expected count and that each expected
 // grouping is present in the result.
 func TestGroupFilesByExtension(t *testing.T) {
-	var buffer bytes.Buffer
-
-	files := map[string]io.Reader{
-		"main.py":     &buffer,
-		"example1.py": &buffer,
-		"example2.py": &buffer,
-		"source.txt":  &buffer,
-		"data.json":   &buffer,
-		"example3.py": &buffer,
+	files := map[string]SourceFile{
+		"main.py":     {Path: "main.py"},
+		"example1.py": {Path: "example1.py"},
+		"example2.py": {Path: "example2.py"},
+		"source.txt":  {Path: "source.txt"},
+		"data.json":   {Path: "data.json"},
+		"example3.py": {Path: "example3.py"},
 	}
 
 	grouped := groupFilesByExtension(files)
@@ -312,3 +322,26 @@ func TestGroupFilesByExtension(t *testing.T) {
 		}
 	}
 }
+
+// TestLatestAssistantMessage tests that latestAssistantMessage ignores user
+// messages and selects the assistant message with the latest creation time.
+func TestLatestAssistantMessage(t *testing.T) {
+	messages := []ThreadMessageResponse{
+		{Id: "msg_1", Role: "assistant", CreatedAt: 10},
+		{Id: "msg_2", Role: "user", CreatedAt: 30},
+		{Id: "msg_3", Role: "assistant", CreatedAt: 20},
+	}
+
+	message, ok := latestAssistantMessage(messages)
+	if !ok {
+		t.Fatal("expected assistant message to be found")
+	}
+
+	if message.Id != "msg_3" {
+		t.Errorf("got: %s, want: %s", message.Id, "msg_3")
+	}
+
+	if _, ok := latestAssistantMessage(messages[1:2]); ok {
+		t.Error("expected no assistant message to be found")
+	}
+}
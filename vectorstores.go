@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// runVectorStoreName is the name of the vector stores created for a single run of generate
+	runVectorStoreName = "goreadme-run"
+	// runVectorStoreExpiryDays is the number of days of inactivity after which run vector stores expire
+	runVectorStoreExpiryDays = 1
+)
+
+// isGoreadmeVectorStore checks if the vector store was created by goreadme. vector stores
+// created before they were marked with metadata are recognised by their name.
+func isGoreadmeVectorStore(store VectorStore) bool {
+	return store.Metadata[assistantMetadataKey] == assistantName || store.Name == assistantName || store.Name == runVectorStoreName
+}
+
+// listGoreadmeVectorStores returns the vector stores of the account that were created by
+// goreadme, or every vector store of the account if all is true.
+func listGoreadmeVectorStores(client ChatGPTService, all bool) ([]VectorStore, error) {
+	stores, err := client.ListVectorStores(ListOptions{}).All()
+	if err != nil {
+		return nil, err
+	}
+	if all {
+		return stores, nil
+	}
+	return slices.DeleteFunc(stores, func(store VectorStore) bool {
+		return !isGoreadmeVectorStore(store)
+	}), nil
+}
+
+// createRunVectorStore creates a vector store for a single run of generate, adds the
+// uploaded files to it using a file batch and waits for the files to be indexed. The
+// vector store expires after a day of inactivity, so it is removed even if it cannot
+// be deleted once the run has finished.
+//
+// Parameters:
+//   - client: The client used to make requests to the ChatGPT API.
+//   - fileIds: The IDs of the uploaded files.
+//
+// Returns:
+//   - string: The ID of the vector store, which is returned even if indexing fails so it can be deleted.
+//   - error: An error if the vector store cannot be created or any file cannot be indexed.
+func createRunVectorStore(client ChatGPTService, fileIds []string) (string, error) {
+	store, err := client.CreateExpiringVectorStore(runVectorStoreName, &VectorStoreExpiresAfter{
+		Anchor: "last_active_at",
+		Days:   runVectorStoreExpiryDays,
+	})
+	if err != nil {
+		return "", err
+	}
+	log.Debug(fmt.Sprintf("created run vector store %s", store.Id))
+
+	batch, err := client.CreateVectorStoreFileBatch(store.Id, fileIds)
+	if err != nil {
+		return store.Id, err
+	}
+
+	batch, err = client.WaitForFileBatchCompletion(store.Id, batch.Id)
+	if err != nil {
+		return store.Id, err
+	}
+	if batch.Status != "completed" || batch.FileCounts.Failed > 0 {
+		return store.Id, fmt.Errorf("file batch %s finished with status %s: %d of %d files failed to index", batch.Id, batch.Status, batch.FileCounts.Failed, batch.FileCounts.Total)
+	}
+	log.Debug(fmt.Sprintf("indexed %d files in run vector store %s", batch.FileCounts.Completed, store.Id))
+	return store.Id, nil
+}
+
+// purgeVectorStore detaches every file from the vector store, deleting the files as
+// well if deleteFiles is true. files are detached even if other files fail.
+//
+// Parameters:
+//   - client: The client used to make requests to the ChatGPT API.
+//   - id: The ID of the vector store.
+//   - deleteFiles: Whether the detached files are deleted.
+//
+// Returns:
+//   - int: The number of files that were purged.
+//   - []error: The errors of any files that could not be purged.
+func purgeVectorStore(client ChatGPTService, id string, deleteFiles bool) (int, []error) {
+	files, err := client.ListVectorStoreFiles(id, ListOptions{}).All()
+	if err != nil {
+		return 0, []error{err}
+	}
+
+	purged := 0
+	errors := []error{}
+	for _, file := range files {
+		if err := client.DeleteVectorStoreFile(id, file.Id); err != nil {
+			errors = append(errors, fmt.Errorf("error detaching file %s: %w", file.Id, err))
+			continue
+		}
+		if deleteFiles {
+			if err := client.DeleteFile(file.Id); err != nil {
+				errors = append(errors, fmt.Errorf("error deleting file %s: %w", file.Id, err))
+				continue
+			}
+		}
+		purged++
+	}
+	return purged, errors
+}
+
+// formatVectorStore formats the details of a vector store, one field per line.
+func formatVectorStore(store VectorStore) string {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("id             %s\n", store.Id))
+	builder.WriteString(fmt.Sprintf("name           %s\n", store.Name))
+	builder.WriteString(fmt.Sprintf("status         %s\n", store.Status))
+	builder.WriteString(fmt.Sprintf("created        %s\n", time.Unix(store.CreatedAt, 0).Format(time.RFC3339)))
+	builder.WriteString(fmt.Sprintf("size           %d bytes\n", store.UsageBytes))
+	builder.WriteString(fmt.Sprintf("files          %d (%d completed, %d in progress, %d failed)\n", store.FileCounts.Total, store.FileCounts.Completed, store.FileCounts.InProgress, store.FileCounts.Failed))
+	if store.ExpiresAfter != nil {
+		builder.WriteString(fmt.Sprintf("expiresAfter   %d days after %s\n", store.ExpiresAfter.Days, store.ExpiresAfter.Anchor))
+	}
+	if store.ExpiresAt > 0 {
+		builder.WriteString(fmt.Sprintf("expiresAt      %s\n", time.Unix(store.ExpiresAt, 0).Format(time.RFC3339)))
+	}
+	return builder.String()
+}
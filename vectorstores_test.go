@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// fakeVectorStoreService implements the vector store methods of ChatGPTService used by
+// the vector store commands, recording the requests made to the vector store.
+type fakeVectorStoreService struct {
+	ChatGPTService
+	expiresAfter *VectorStoreExpiresAfter
+	batchFiles   []string
+	batch        VectorStoreFileBatch
+	files        []VectorStoreFile
+	detached     []string
+	deleted      []string
+	failDetach   string
+}
+
+func (service *fakeVectorStoreService) CreateExpiringVectorStore(name string, expiresAfter *VectorStoreExpiresAfter) (VectorStore, error) {
+	service.expiresAfter = expiresAfter
+	return VectorStore{Id: "vs_run", Name: name}, nil
+}
+
+func (service *fakeVectorStoreService) CreateVectorStoreFileBatch(vectorStoreId string, fileIds []string) (VectorStoreFileBatch, error) {
+	service.batchFiles = fileIds
+	return VectorStoreFileBatch{Id: "batch_test", VectorStoreId: vectorStoreId, Status: "in_progress"}, nil
+}
+
+func (service *fakeVectorStoreService) WaitForFileBatchCompletion(vectorStoreId, batchId string) (VectorStoreFileBatch, error) {
+	return service.batch, nil
+}
+
+func (service *fakeVectorStoreService) ListVectorStoreFiles(vectorStoreId string, opts ListOptions) *ListIterator[VectorStoreFile] {
+	return NewListIterator(opts, func(opts ListOptions) (ListResponse[VectorStoreFile], error) {
+		return ListResponse[VectorStoreFile]{Data: service.files}, nil
+	})
+}
+
+func (service *fakeVectorStoreService) DeleteVectorStoreFile(vectorStoreId, fileId string) error {
+	if fileId == service.failDetach {
+		return errors.New("detach failed")
+	}
+	service.detached = append(service.detached, fileId)
+	return nil
+}
+
+func (service *fakeVectorStoreService) DeleteFile(id string) error {
+	service.deleted = append(service.deleted, id)
+	return nil
+}
+
+// TestCreateRunVectorStore tests that run vector stores expire after inactivity, that the
+// uploaded files are added in a single batch, and that failed indexing is reported while
+// still returning the vector store so it can be deleted.
+func TestCreateRunVectorStore(t *testing.T) {
+	service := &fakeVectorStoreService{
+		batch: VectorStoreFileBatch{Id: "batch_test", Status: "completed", FileCounts: FileCounts{Completed: 2, Total: 2}},
+	}
+
+	id, err := createRunVectorStore(service, []string{"file_a", "file_b"})
+	if err != nil || id != "vs_run" {
+		t.Fatalf("got: %s (%v), want: %s", id, err, "vs_run")
+	}
+
+	want := VectorStoreExpiresAfter{Anchor: "last_active_at", Days: runVectorStoreExpiryDays}
+	if service.expiresAfter == nil || *service.expiresAfter != want {
+		t.Errorf("got: %+v, want: %+v", service.expiresAfter, want)
+	}
+	if !slices.Equal(service.batchFiles, []string{"file_a", "file_b"}) {
+		t.Errorf("got: %v, want: %v", service.batchFiles, []string{"file_a", "file_b"})
+	}
+
+	service.batch = VectorStoreFileBatch{Id: "batch_test", Status: "completed", FileCounts: FileCounts{Completed: 1, Failed: 1, Total: 2}}
+	id, err = createRunVectorStore(service, []string{"file_a", "file_b"})
+	if err == nil || id != "vs_run" {
+		t.Errorf("got: %s (%v), want: %s with an error", id, err, "vs_run")
+	}
+}
+
+// TestPurgeVectorStore tests that every file is detached from the vector store and deleted,
+// continuing after files that cannot be detached.
+func TestPurgeVectorStore(t *testing.T) {
+	service := &fakeVectorStoreService{
+		files:      []VectorStoreFile{{Id: "file_a"}, {Id: "file_b"}, {Id: "file_c"}},
+		failDetach: "file_b",
+	}
+
+	purged, errs := purgeVectorStore(service, "vs_test", true)
+	if purged != 2 || len(errs) != 1 {
+		t.Errorf("got: %d purged, %d errors, want: %d purged, %d errors", purged, len(errs), 2, 1)
+	}
+	if !slices.Equal(service.detached, []string{"file_a", "file_c"}) || !slices.Equal(service.deleted, []string{"file_a", "file_c"}) {
+		t.Errorf("got: detached %v, deleted %v, want: %v", service.detached, service.deleted, []string{"file_a", "file_c"})
+	}
+
+	service.detached, service.deleted = nil, nil
+	service.failDetach = ""
+	purgeVectorStore(service, "vs_test", false)
+	if len(service.deleted) != 0 || len(service.detached) != 3 {
+		t.Errorf("got: detached %v, deleted %v, want: every file detached and none deleted", service.detached, service.deleted)
+	}
+}